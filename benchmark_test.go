@@ -0,0 +1,88 @@
+package infuse_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+type discardResponse struct {
+	header http.Header
+}
+
+func (d *discardResponse) Header() http.Header {
+	return d.header
+}
+
+func (d *discardResponse) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (*discardResponse) WriteHeader(int) {}
+
+func nextHandler(response http.ResponseWriter, request *http.Request) {
+	infuse.Next(response, request)
+}
+
+func benchmarkChain(b *testing.B, handler infuse.Handler, depth int) {
+	for i := 0; i < depth; i++ {
+		handler = handler.HandleFunc(nextHandler)
+	}
+	response := &discardResponse{http.Header{}}
+	request := &http.Request{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(response, request)
+	}
+}
+
+func BenchmarkNext(b *testing.B) {
+	for _, depth := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			benchmarkChain(b, infuse.New(), depth)
+		})
+	}
+}
+
+func BenchmarkNextWithValues(b *testing.B) {
+	for _, depth := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+				infuse.Set(response, "value")
+				infuse.Next(response, request)
+			})
+			benchmarkChain(b, handler, depth)
+		})
+	}
+}
+
+func BenchmarkBranch(b *testing.B) {
+	for _, depth := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			branch := infuse.New().HandleFunc(nextHandler)
+			handler := infuse.New().When(func(*http.Request) bool { return true }, branch)
+			benchmarkChain(b, handler, depth)
+		})
+	}
+}
+
+func TestAllocationsIndependentOfDepth(t *testing.T) {
+	allocs := func(depth int) float64 {
+		handler := infuse.New()
+		for i := 0; i < depth; i++ {
+			handler = handler.HandleFunc(nextHandler)
+		}
+		response := &discardResponse{http.Header{}}
+		request := &http.Request{}
+		return testing.AllocsPerRun(100, func() {
+			handler.ServeHTTP(response, request)
+		})
+	}
+	if shallow, deep := allocs(1), allocs(100); deep > shallow {
+		t.Fatalf("Expected allocations not to grow with depth, got %v for 1 layer and %v for 100 layers.", shallow, deep)
+	}
+}
@@ -0,0 +1,49 @@
+package infuse
+
+import "net/http"
+
+// Branch serves the http.Handlers attached to the provided infuse.Handler
+// as if they were attached to the current infuse.Handler in place of the
+// http.Handler that calls Branch. The http.Handlers attached to the branch
+// share the context values of the current infuse.Handler, and a call to
+// infuse.Next from the last http.Handler attached to the branch serves the
+// http.Handlers attached after the http.Handler that called Branch. Branch
+// is always called from within an http.Handler that is handled by an
+// infuse.Handler, in place of Next.
+//
+// If the provided branch is not created by infuse.New, it is served as if it
+// were an infuse.Handler attached with Handle.
+//
+// The boolean return value indicates whether the call succeeded. Branch will
+// return false if the branch and the rest of the middleware chain are both
+// empty, or if the response is invalid.
+func Branch(response http.ResponseWriter, request *http.Request, branch Handler) bool {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return false
+	}
+	layers, ok := branch.(*layer)
+	if !ok {
+		branch.ServeHTTP(response, request)
+		return true
+	}
+	return sharedResponse.branch(layers, request)
+}
+
+func (l *layer) When(predicate func(*http.Request) bool, branch Handler) Handler {
+	return l.attach("", KindWhen, branch, conditional(predicate, true, branch))
+}
+
+func (l *layer) Unless(predicate func(*http.Request) bool, branch Handler) Handler {
+	return l.attach("", KindUnless, branch, conditional(predicate, false, branch))
+}
+
+func conditional(predicate func(*http.Request) bool, match bool, branch Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if predicate(request) == match {
+			Branch(response, request, branch)
+		} else {
+			Next(response, request)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package infuse_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+var matchingBranchFixture = `
+start first
+attempting next for first
+start branch-first
+attempting next for branch-first
+start branch-second
+attempting next for branch-second
+start last
+attempting next for last
+no next for last
+end last
+finished next for branch-second
+end branch-second
+finished next for branch-first
+end branch-first
+finished next for first
+end first`
+
+var skippedBranchFixture = `
+start first
+attempting next for first
+start last
+attempting next for last
+no next for last
+end last
+finished next for first
+end first`
+
+func TestWhen(t *testing.T) {
+	branch := infuse.New().HandleFunc(buildHandler("branch-first", 1))
+	branch = branch.HandleFunc(buildHandler("branch-second", 1))
+
+	handler := infuse.New().HandleFunc(buildHandler("first", 1))
+	handler = handler.When(isAdmin, branch)
+	handler = handler.HandleFunc(buildHandler("last", 1))
+
+	testHandlerResponse(t, serveRequest(handler, adminRequest()), matchingBranchFixture)
+	testHandlerResponse(t, serve(handler), skippedBranchFixture)
+}
+
+func TestUnless(t *testing.T) {
+	branch := infuse.New().HandleFunc(buildHandler("branch-first", 1))
+	branch = branch.HandleFunc(buildHandler("branch-second", 1))
+
+	handler := infuse.New().HandleFunc(buildHandler("first", 1))
+	handler = handler.Unless(isAdmin, branch)
+	handler = handler.HandleFunc(buildHandler("last", 1))
+
+	testHandlerResponse(t, serve(handler), matchingBranchFixture)
+	testHandlerResponse(t, serveRequest(handler, adminRequest()), skippedBranchFixture)
+}
+
+var shortCircuitBranchFixture = `
+start first
+attempting next for first
+denied
+finished next for first
+end first`
+
+func TestBranchContextAndShortCircuit(t *testing.T) {
+	branch := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		if infuse.Get(response) != "outer value" {
+			t.Fatal("Expected branch to share context values.")
+		}
+		infuse.Set(response, "branch value")
+		infuse.Next(response, request)
+	})
+	branch = branch.When(isAdmin, infuse.New().HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(response, "denied")
+	}))
+
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.Set(response, "outer value")
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(buildHandler("first", 1))
+	handler = handler.When(func(*http.Request) bool { return true }, branch)
+	handler = handler.HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(response, "last: %s\n", infuse.Get(response))
+	})
+
+	testHandlerResponse(t, serveRequest(handler, adminRequest()), shortCircuitBranchFixture)
+	testHandlerResponse(t, serve(handler), "start first\nattempting next for first\nlast: branch value\nfinished next for first\nend first")
+}
+
+func TestBranchWithEmptyBranch(t *testing.T) {
+	handler := infuse.New().HandleFunc(buildHandler("first", 1))
+	handler = handler.When(isAdmin, infuse.New())
+	handler = handler.HandleFunc(buildHandler("last", 1))
+	testHandlerResponse(t, serveRequest(handler, adminRequest()), skippedBranchFixture)
+}
+
+func TestInvalidResponseForBranch(t *testing.T) {
+	if ok := infuse.Branch(nil, &http.Request{}, infuse.New()); ok {
+		t.Fatal("Expected failure to serve branch with invalid response.")
+	}
+}
+
+func isAdmin(request *http.Request) bool {
+	return request.URL != nil && request.URL.Path == "/admin"
+}
+
+func adminRequest() *http.Request {
+	return &http.Request{URL: &url.URL{Path: "/admin"}}
+}
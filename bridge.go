@@ -0,0 +1,75 @@
+package infuse
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithContext returns a shallow copy of the provided request with a context
+// that reads through to the context values shared by the http.Handlers
+// attached to the same infuse.Handler. A value associated with a key by
+// SetValue (or with a Key by Key.Set) can then be retrieved from the
+// request's context with context.Context.Value, so that it is visible to
+// libraries that only know about request.Context(). Keys that do not have an
+// associated context value are looked up in the original request context.
+//
+// The bridged context reads the context values each time Value is called,
+// so values set after the call to WithContext are also visible. As the
+// context values are not safe for concurrent use, the bridged context may be
+// read from other goroutines only while no http.Handler sets context values,
+// as when the goroutines are started by Parallel.
+//
+// WithContext will return the provided request unchanged if the provided
+// response is invalid.
+func WithContext(response http.ResponseWriter, request *http.Request) *http.Request {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return request
+	}
+	if bridged, ok := request.Context().(*bridgedContext); ok && bridged.response == sharedResponse {
+		return request
+	}
+	return request.WithContext(&bridgedContext{request.Context(), sharedResponse})
+}
+
+// BridgeContext is an http.HandlerFunc that calls infuse.Next with a request
+// returned by WithContext. When attached to an infuse.Handler, all
+// http.Handlers attached after it can read context values from the request
+// context.
+func BridgeContext(response http.ResponseWriter, request *http.Request) {
+	Next(response, WithContext(response, request))
+}
+
+// Promote copies the values associated with the provided keys in the request
+// context to the context values shared by the http.Handlers attached to the
+// same infuse.Handler. Keys without a value in the request context are
+// skipped. This makes values added to the request context by other
+// middleware available to Value and Key.Get.
+//
+// The boolean return value indicates whether promoting the values succeeded.
+// Promote will return false if the provided response is invalid.
+func Promote(response http.ResponseWriter, request *http.Request, keys ...interface{}) bool {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return false
+	}
+	ctx := request.Context()
+	for _, key := range keys {
+		if value := ctx.Value(key); value != nil {
+			sharedResponse.setValue(key, value)
+		}
+	}
+	return true
+}
+
+type bridgedContext struct {
+	context.Context
+	response infuseResponse
+}
+
+func (b *bridgedContext) Value(key interface{}) interface{} {
+	if value, ok := b.response.value(key); ok {
+		return value
+	}
+	return b.Context.Value(key)
+}
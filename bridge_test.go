@@ -0,0 +1,53 @@
+package infuse_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+type requestKey struct{}
+
+var bridgeFixture = `
+first: first value
+request: request value
+first: new first value
+request: request value
+promoted: request value`
+
+func TestBridgeContext(t *testing.T) {
+	handler := infuse.New().HandleFunc(buildSetValueHandler(firstKey{}, "first value"))
+	handler = handler.HandleFunc(infuse.BridgeContext)
+	handler = handler.HandleFunc(outputRequestContextHandler)
+	handler = handler.HandleFunc(buildSetValueHandler(firstKey{}, "new first value"))
+	handler = handler.HandleFunc(outputRequestContextHandler)
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.Promote(response, request, requestKey{}, secondKey{})
+		fmt.Fprintf(response, "promoted: %s\n", infuse.Value(response, requestKey{}))
+		if infuse.HasValue(response, secondKey{}) {
+			t.Fatal("Expected missing request context value not to be promoted.")
+		}
+	})
+
+	request := (&http.Request{}).WithContext(context.WithValue(context.Background(), requestKey{}, "request value"))
+	testHandlerResponse(t, serveRequest(handler, request), bridgeFixture)
+}
+
+func TestInvalidResponseForBridge(t *testing.T) {
+	request := &http.Request{}
+	if bridged := infuse.WithContext(nil, request); bridged != request {
+		t.Fatal("Expected unchanged request for invalid response.")
+	}
+	if ok := infuse.Promote(nil, request, requestKey{}); ok {
+		t.Fatal("Expected failure to promote values for invalid response.")
+	}
+}
+
+func outputRequestContextHandler(response http.ResponseWriter, request *http.Request) {
+	fmt.Fprintf(response, "first: %s\n", request.Context().Value(firstKey{}))
+	fmt.Fprintf(response, "request: %s\n", request.Context().Value(requestKey{}))
+	infuse.Next(response, request)
+}
@@ -0,0 +1,66 @@
+package infuse
+
+import "net/http"
+
+// NextCapture is the same as Next, but the subsequent http.Handlers in the
+// middleware chain write to a new Recording instead of the current response,
+// as if it were passed to NextWith. Nothing is written to the current
+// response until the Recording is committed with Commit, so the caller may
+// inspect or modify the Recording first, or discard it. This allows
+// middleware to retry the rest of the middleware chain, or to compute
+// headers (such as an ETag) from the body before it is written.
+//
+// The http.Handlers served by NextCapture are isolated from the current
+// response, so that a discarded Recording leaves no trace: any error that
+// propagates up from them is stored in the Recording (see Recording.Err)
+// instead of propagating further, and any hooks they register with
+// BeforeWrite are only registered with the current response when the
+// Recording is committed.
+//
+// The boolean return value indicates whether the call succeeded. NextCapture
+// will return a nil Recording and false if no subsequent http.Handler is
+// available, if the request was aborted, or if the response is invalid.
+func NextCapture(response http.ResponseWriter, request *http.Request) (*Recording, bool) {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return nil, false
+	}
+	recording := &Recording{}
+	prevErr := sharedResponse.failure()
+	prevHooks := sharedResponse.swapHooks(nil)
+	sharedResponse.fail(nil)
+	ok = NextWith(response, recording, request)
+	recording.Err = sharedResponse.failure()
+	recording.hooks = sharedResponse.swapHooks(prevHooks)
+	sharedResponse.fail(prevErr)
+	if !ok {
+		return nil, false
+	}
+	return recording, true
+}
+
+// Commit writes the Recording to the provided response. The headers of the
+// Recording replace any headers with the same keys in the provided response,
+// and the status code is written if the Recording has one. Any hooks
+// registered with BeforeWrite while the Recording was captured are
+// registered with the provided response before it is written. Commit returns
+// any error that occurs while writing the body. The error stored in Err is
+// not returned, so that the caller may decide whether to propagate it.
+func (r *Recording) Commit(response http.ResponseWriter) error {
+	for _, hook := range r.hooks {
+		BeforeWrite(response, hook)
+	}
+	r.hooks = nil
+	header := response.Header()
+	for key, values := range r.header {
+		header[key] = append([]string(nil), values...)
+	}
+	if r.Status != 0 {
+		response.WriteHeader(r.Status)
+	}
+	if r.Body.Len() == 0 {
+		return nil
+	}
+	_, err := response.Write(r.Body.Bytes())
+	return err
+}
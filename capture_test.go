@@ -0,0 +1,138 @@
+package infuse_test
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+func TestNextCapture(t *testing.T) {
+	hooked := false
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.BeforeWrite(response, func(http.Header, int) {
+			hooked = true
+		})
+		recording, ok := infuse.NextCapture(response, request)
+		if !ok {
+			t.Fatal("Expected next to succeed.")
+		}
+		if infuse.HeaderWritten(response) || hooked {
+			t.Fatal("Expected nothing to be written before commit.")
+		}
+		recording.Header().Set("ETag", fmt.Sprintf(`"%x"`, sha1.Sum(recording.Body.Bytes())))
+		recording.Body.WriteString(" rewritten")
+		if err := recording.Commit(response); err != nil {
+			t.Fatal(err)
+		}
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		response.Header().Set("X-Downstream", "yes")
+		response.WriteHeader(http.StatusCreated)
+		fmt.Fprint(response, "body")
+	})
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+
+	if response.Code != http.StatusCreated || response.Body.String() != "body rewritten" {
+		t.Fatalf("Unexpected response: %d %q", response.Code, response.Body.String())
+	}
+	if response.Header().Get("X-Downstream") != "yes" || response.Header().Get("ETag") != fmt.Sprintf(`"%x"`, sha1.Sum([]byte("body"))) {
+		t.Fatalf("Unexpected headers: %v", response.Header())
+	}
+	if !hooked {
+		t.Fatal("Expected hooks to run when the recording is committed.")
+	}
+}
+
+func TestNextCaptureRetry(t *testing.T) {
+	attempts := 0
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		for {
+			recording, _ := infuse.NextCapture(response, request)
+			if recording.Status != http.StatusServiceUnavailable {
+				recording.Commit(response)
+				return
+			}
+		}
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts < 3 {
+			http.Error(response, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(response, "attempt %d", attempts)
+	})
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+
+	if response.Code != http.StatusOK || response.Body.String() != "attempt 3" || strings.Contains(response.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("Expected only the last attempt to be written, got: %d %q %v", response.Code, response.Body.String(), response.Header())
+	}
+}
+
+func TestNextCaptureRetryAfterError(t *testing.T) {
+	transient := errors.New("transient")
+	var errs []error
+	var hooks []int
+	handler := infuse.New().OnError(func(_ http.ResponseWriter, _ *http.Request, err error) {
+		t.Fatalf("Expected no error to propagate, got: %s", err)
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		for {
+			recording, _ := infuse.NextCapture(response, request)
+			errs = append(errs, recording.Err)
+			if recording.Err == nil {
+				recording.Commit(response)
+				return
+			}
+		}
+	})
+	attempts := 0
+	handler = handler.HandleErr(func(response http.ResponseWriter, _ *http.Request) error {
+		attempts++
+		attempt := attempts
+		infuse.BeforeWrite(response, func(http.Header, int) {
+			hooks = append(hooks, attempt)
+		})
+		if attempt == 1 {
+			return transient
+		}
+		fmt.Fprintf(response, "attempt %d", attempt)
+		return nil
+	})
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+
+	if response.Code != http.StatusOK || response.Body.String() != "attempt 2" {
+		t.Fatalf("Expected only the last attempt to be written, got: %d %q", response.Code, response.Body.String())
+	}
+	if len(errs) != 2 || errs[0] != transient || errs[1] != nil {
+		t.Fatalf("Expected each recording to hold the error of its attempt, got: %v", errs)
+	}
+	if len(hooks) != 1 || hooks[0] != 2 {
+		t.Fatalf("Expected only the hooks of the committed attempt to run, got: %v", hooks)
+	}
+}
+
+func TestNextCaptureWithoutNext(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		if recording, ok := infuse.NextCapture(response, request); ok || recording != nil {
+			t.Fatal("Expected failure to capture without next handler.")
+		}
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), &http.Request{})
+
+	if recording, ok := infuse.NextCapture(nil, &http.Request{}); ok || recording != nil {
+		t.Fatal("Expected failure to capture with invalid response.")
+	}
+}
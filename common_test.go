@@ -15,7 +15,11 @@ func testHandlerResponse(t *testing.T, body string, fixture string) {
 }
 
 func serve(handler http.Handler) string {
+	return serveRequest(handler, &http.Request{})
+}
+
+func serveRequest(handler http.Handler, request *http.Request) string {
 	response := httptest.NewRecorder()
-	handler.ServeHTTP(response, &http.Request{})
+	handler.ServeHTTP(response, request)
 	return response.Body.String()
 }
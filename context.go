@@ -6,31 +6,16 @@ import "net/http"
 // attached to the same infuse.Handler. The context value is associated with
 // an http.ResponseWriter, so it has the same life cycle as the provided
 // response. Get will return nil if the provided response does not have an
-// associated context value.
+// associated context value. As with Next, the provided response may be a
+// wrapper around the http.ResponseWriter provided by the infuse.Handler.
 //
 // If the context value is a pointer, map, or slice, then changes to the data
 // in one http.Handler will be seen by other http.Handlers that share it.
 //
-// To retrieve a value of a particular type, consider wrapping Get and Set in
-// another function. For example, this function could be used to share a map
-// of floating-point numbers between middleware handlers:
-//
-//   func HandlerMap(response http.ResponseWriter) map[string]float64 {
-//      handlerMap, ok := infuse.Get(response).(map[string]float64)
-//      if !ok || handlerMap == nil {
-//         handlerMap = make(map[string]float64)
-//         if ok := infuse.Set(response, handlerMap); !ok {
-//            return nil
-//         }
-//      }
-//      return handlerMap
-//   }
+// Get and Set share a single context value. To share multiple values between
+// independent middleware handlers, use Value and SetValue instead.
 func Get(response http.ResponseWriter) interface{} {
-	sharedResponse, ok := response.(infuseResponse)
-	if !ok {
-		return nil
-	}
-	return sharedResponse.get()
+	return Value(response, singleKey{})
 }
 
 // Set will store a context value that is shared by all http.Handlers attached
@@ -40,23 +25,137 @@ func Get(response http.ResponseWriter) interface{} {
 // The boolean return value indicates whether the setting the context value
 // succeeded. Set will return false if the provided response is invalid.
 func Set(response http.ResponseWriter, value interface{}) bool {
-	sharedResponse, ok := response.(infuseResponse)
+	return SetValue(response, singleKey{}, value)
+}
+
+// Value will retrieve the context value associated with the provided key.
+// Like Get, the value is shared by all http.Handlers attached to the same
+// infuse.Handler. Value will return nil if the provided response is invalid
+// or if no value is associated with the key.
+//
+// As with context.WithValue, keys should be comparable values of an
+// unexported type defined by the package that uses them, so that middleware
+// from different packages cannot collide. For example:
+//
+//   type userKey struct{}
+//
+//   func User(response http.ResponseWriter) string {
+//      user, _ := infuse.Value(response, userKey{}).(string)
+//      return user
+//   }
+//
+//   func SetUser(response http.ResponseWriter, user string) bool {
+//      return infuse.SetValue(response, userKey{}, user)
+//   }
+func Value(response http.ResponseWriter, key interface{}) interface{} {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return nil
+	}
+	value, _ := sharedResponse.value(key)
+	return value
+}
+
+// SetValue will associate a context value with the provided key. Values
+// associated with other keys are not affected.
+//
+// The boolean return value indicates whether setting the context value
+// succeeded. SetValue will return false if the provided response is invalid.
+func SetValue(response http.ResponseWriter, key, value interface{}) bool {
+	sharedResponse, ok := find(response)
 	if !ok {
 		return false
 	}
-	sharedResponse.set(value)
+	sharedResponse.setValue(key, value)
 	return true
 }
 
+// HasValue reports whether a context value is associated with the provided
+// key, even if that value is nil.
+func HasValue(response http.ResponseWriter, key interface{}) bool {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return false
+	}
+	_, ok = sharedResponse.value(key)
+	return ok
+}
+
+// DeleteValue will remove the context value associated with the provided key.
+//
+// The boolean return value indicates whether deleting the context value
+// succeeded. DeleteValue will return false if the provided response is
+// invalid.
+func DeleteValue(response http.ResponseWriter, key interface{}) bool {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return false
+	}
+	sharedResponse.deleteValue(key)
+	return true
+}
+
+type singleKey struct{}
+
+// A contextualResponse is tracked if it writes directly to the response
+// that the infuse.Handler is served with, instead of a replacement provided
+// to NextWith.
 type contextualResponse struct {
 	http.ResponseWriter
-	context interface{}
+	*state
+	tracked bool
 }
 
-func (c *contextualResponse) get() interface{} {
-	return c.context
+// state is shared by every response provided to the http.Handlers attached
+// to the same infuse.Handler while it is served.
+type state struct {
+	values  map[interface{}]interface{}
+	onSet   func(key, value interface{})
+	parent  infuseResponse
+	scope   scope
+	err     error
+	aborted bool
+
+	wroteHeader bool
+	status      int
+	written     int64
+	hooks       []func(http.Header, int)
+
+	observers []Observer
+
+	panicLayer *layer
+	panicIndex int
+}
+
+func (s *state) value(key interface{}) (interface{}, bool) {
+	if s.scope == scopeShared {
+		return s.parent.value(key)
+	}
+	value, ok := s.values[key]
+	if !ok && s.scope == scopeInherited {
+		return s.parent.value(key)
+	}
+	return value, ok
 }
 
-func (c *contextualResponse) set(value interface{}) {
-	c.context = value
+func (s *state) setValue(key, value interface{}) {
+	if s.scope == scopeShared {
+		s.parent.setValue(key, value)
+	} else {
+		if s.values == nil {
+			s.values = make(map[interface{}]interface{})
+		}
+		s.values[key] = value
+	}
+	if s.onSet != nil {
+		s.onSet(key, value)
+	}
+}
+
+func (s *state) deleteValue(key interface{}) {
+	if s.scope == scopeShared {
+		s.parent.deleteValue(key)
+		return
+	}
+	delete(s.values, key)
 }
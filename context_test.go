@@ -41,6 +41,73 @@ func TestInvalidResponseForGetAndSet(t *testing.T) {
 	}
 }
 
+type firstKey struct{}
+type secondKey struct{}
+
+var valuesFixture = `
+first: first value
+second: <nil> (false)
+first: first value
+second: second value
+single: single value
+first: <nil> (false)
+second: second value`
+
+func TestValues(t *testing.T) {
+	handler := infuse.New().HandleFunc(buildSetValueHandler(firstKey{}, "first value"))
+	handler = handler.HandleFunc(buildOutputValuesHandler)
+	handler = handler.HandleFunc(buildSetValueHandler(secondKey{}, "second value"))
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.Set(response, "single value")
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(buildOutputValuesHandler)
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(response, "single: %s\n", infuse.Get(response))
+		infuse.DeleteValue(response, firstKey{})
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(buildOutputValuesHandler)
+	testHandlerResponse(t, serve(handler), valuesFixture)
+}
+
+func TestInvalidResponseForValues(t *testing.T) {
+	if value := infuse.Value(nil, firstKey{}); value != nil {
+		t.Fatalf("Expected nil value from invalid response, got %s.", value)
+	}
+	if ok := infuse.SetValue(nil, firstKey{}, "value"); ok {
+		t.Fatal("Expected failure to set value on invalid response.")
+	}
+	if ok := infuse.HasValue(nil, firstKey{}); ok {
+		t.Fatal("Expected invalid response to have no values.")
+	}
+	if ok := infuse.DeleteValue(nil, firstKey{}); ok {
+		t.Fatal("Expected failure to delete value on invalid response.")
+	}
+}
+
+func buildSetValueHandler(key, value interface{}) func(http.ResponseWriter, *http.Request) {
+	return func(response http.ResponseWriter, request *http.Request) {
+		infuse.SetValue(response, key, value)
+		infuse.Next(response, request)
+	}
+}
+
+func buildOutputValuesHandler(response http.ResponseWriter, request *http.Request) {
+	for _, key := range []interface{}{firstKey{}, secondKey{}} {
+		name := "first"
+		if key == (secondKey{}) {
+			name = "second"
+		}
+		if infuse.HasValue(response, key) {
+			fmt.Fprintf(response, "%s: %s\n", name, infuse.Value(response, key))
+		} else {
+			fmt.Fprintf(response, "%s: %v (false)\n", name, infuse.Value(response, key))
+		}
+	}
+	infuse.Next(response, request)
+}
+
 func createMapHandler(response http.ResponseWriter, request *http.Request) {
 	if ok := infuse.Set(response, make(map[string]string)); !ok {
 		panic("Failed to set map.")
@@ -0,0 +1,73 @@
+package infuse
+
+import "net/http"
+
+// Abort marks the request as aborted. Once a request is aborted, calls to
+// Next (and to equivalent functions, such as NextWith, Branch, SkipTo, and
+// Last) from any http.Handler attached to the same infuse.Handler return
+// false without serving another http.Handler. Middleware that runs after
+// calling Next can check IsAborted to avoid writing to the response.
+//
+// If the infuse.Handler is nested in another infuse.Handler, the request is
+// also marked as aborted in the outer infuse.Handler once the nested
+// infuse.Handler returns.
+//
+// The boolean return value indicates whether the call succeeded. Abort will
+// return false if the response is invalid.
+func Abort(response http.ResponseWriter) bool {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return false
+	}
+	sharedResponse.abort()
+	return true
+}
+
+// IsAborted reports whether the request was marked as aborted by Abort.
+// IsAborted will return false if the response is invalid.
+func IsAborted(response http.ResponseWriter) bool {
+	sharedResponse, ok := find(response)
+	return ok && sharedResponse.isAborted()
+}
+
+// SkipTo serves the next http.Handler in the middleware chain that was
+// attached with the provided name (see Handler.HandleNamed), skipping any
+// http.Handlers attached before it. It is otherwise the same as Next.
+//
+// The boolean return value indicates whether the call succeeded. SkipTo
+// will return false if no subsequent http.Handler has the provided name
+// (including if the name is empty), if the request was aborted, or if the
+// response is invalid.
+func SkipTo(response http.ResponseWriter, request *http.Request, name string) bool {
+	sharedResponse, ok := find(response)
+	return ok && sharedResponse.skipTo(name, request)
+}
+
+// Last serves the last http.Handler in the middleware chain, skipping any
+// http.Handlers attached before it. This is useful for skipping optional
+// middleware and going straight to the http.Handler that generates the
+// response. It is otherwise the same as Next.
+//
+// The boolean return value indicates whether the call succeeded. Last will
+// return false if no subsequent http.Handler is available, if the request
+// was aborted, or if the response is invalid.
+func Last(response http.ResponseWriter, request *http.Request) bool {
+	sharedResponse, ok := find(response)
+	return ok && sharedResponse.last(request)
+}
+
+func (l *layeredResponse) skipTo(name string, request *http.Request) bool {
+	return l.serveNext(l.contextualResponse, request, named(name), false)
+}
+
+func (l *layeredResponse) last(request *http.Request) bool {
+	return l.serveNext(l.contextualResponse, request, nil, true)
+}
+
+func (s *state) abort() {
+	s.aborted = true
+}
+
+func (s *state) isAborted() bool {
+	return s.aborted
+}
@@ -0,0 +1,162 @@
+package infuse_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+var abortFixture = `
+start first
+attempting next for first
+aborting
+finished next for first
+first sees abort
+end first`
+
+func TestAbort(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		fmt.Fprintln(response, "start first")
+		fmt.Fprintln(response, "attempting next for first")
+		if infuse.Next(response, request) {
+			fmt.Fprintln(response, "finished next for first")
+		}
+		if infuse.IsAborted(response) {
+			fmt.Fprintln(response, "first sees abort")
+		}
+		fmt.Fprintln(response, "end first")
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		if infuse.IsAborted(response) {
+			t.Fatal("Expected request not to be aborted yet.")
+		}
+		fmt.Fprintln(response, "aborting")
+		if !infuse.Abort(response) {
+			t.Fatal("Expected abort to succeed.")
+		}
+		if infuse.Next(response, request) {
+			t.Fatal("Expected next to fail after abort.")
+		}
+		if infuse.Last(response, request) {
+			t.Fatal("Expected last to fail after abort.")
+		}
+	})
+	handler = handler.HandleFunc(buildHandler("never", 0))
+
+	testHandlerResponse(t, serve(handler), abortFixture)
+}
+
+func TestNestedAbort(t *testing.T) {
+	nested := infuse.New().HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		infuse.Abort(response)
+	})
+
+	aborted := false
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.Next(response, request)
+		aborted = infuse.IsAborted(response)
+	})
+	handler = handler.Stack(nested)
+	handler = handler.HandleFunc(buildHandler("never", 0))
+
+	if body := serve(handler); body != "" {
+		t.Fatalf("Expected no response, got: %q", body)
+	}
+	if !aborted {
+		t.Fatal("Expected abort to propagate to outer handler.")
+	}
+}
+
+var skipToFixture = `
+start first
+attempting next for first
+start target
+attempting next for target
+start last
+attempting next for last
+no next for last
+end last
+finished next for target
+end target
+finished next for first
+end first`
+
+func TestSkipTo(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		if infuse.SkipTo(response, request, "missing") {
+			t.Fatal("Expected skip to missing layer to fail.")
+		}
+		if infuse.SkipTo(response, request, "") {
+			t.Fatal("Expected skip to unnamed layer to fail.")
+		}
+		fmt.Fprintln(response, "start first")
+		fmt.Fprintln(response, "attempting next for first")
+		if infuse.SkipTo(response, request, "target") {
+			fmt.Fprintln(response, "finished next for first")
+		}
+		fmt.Fprintln(response, "end first")
+	})
+	handler = handler.HandleFunc(buildHandler("skipped", 1))
+	handler = handler.HandleNamed("target", http.HandlerFunc(buildHandler("target", 1)))
+	handler = handler.HandleFunc(buildHandler("last", 1))
+
+	testHandlerResponse(t, serve(handler), skipToFixture)
+}
+
+var lastFixture = `
+start first
+attempting next for first
+start branch
+attempting next for branch
+start last
+attempting next for last
+no next for last
+end last
+finished next for branch
+end branch
+finished next for first
+end first`
+
+func TestLast(t *testing.T) {
+	branch := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		fmt.Fprintln(response, "start branch")
+		fmt.Fprintln(response, "attempting next for branch")
+		if infuse.Last(response, request) {
+			fmt.Fprintln(response, "finished next for branch")
+		}
+		fmt.Fprintln(response, "end branch")
+	})
+	branch = branch.HandleFunc(buildHandler("skipped-branch", 1))
+
+	handler := infuse.New().HandleFunc(buildHandler("first", 1))
+	handler = handler.When(isAdmin, branch)
+	handler = handler.HandleFunc(buildHandler("skipped", 1))
+	handler = handler.HandleFunc(buildHandler("last", 1))
+
+	testHandlerResponse(t, serveRequest(handler, adminRequest()), lastFixture)
+
+	handler = infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		if infuse.Last(response, request) {
+			t.Fatal("Expected last to fail without subsequent handlers.")
+		}
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), &http.Request{})
+}
+
+func TestInvalidResponseForControl(t *testing.T) {
+	if infuse.Abort(nil) {
+		t.Fatal("Expected failure to abort with invalid response.")
+	}
+	if infuse.IsAborted(nil) {
+		t.Fatal("Expected invalid response not to be aborted.")
+	}
+	if infuse.SkipTo(nil, &http.Request{}, "name") {
+		t.Fatal("Expected failure to skip with invalid response.")
+	}
+	if infuse.Last(nil, &http.Request{}) {
+		t.Fatal("Expected failure to skip with invalid response.")
+	}
+}
@@ -0,0 +1,73 @@
+package infuse
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrNoNext is returned by NextErr when no subsequent http.Handler is
+// available or when the provided response is invalid.
+var ErrNoNext = errors.New("infuse: no next handler")
+
+// Fail records an error for the current request. The error propagates up the
+// middleware chain: it is returned by the call to NextErr that served the
+// failing http.Handler, and otherwise handled by the nearest handler attached
+// before it with Handler.OnError. An error that is not handled within an
+// infuse.Handler propagates to the infuse.Handler nested above it, if there
+// is one, or else results in a 500 Internal Server Error response.
+//
+// Fail is called with the error returned by an http.Handler attached with
+// Handler.HandleErr, so it only needs to be called directly from
+// http.Handlers that do not return errors.
+//
+// The boolean return value indicates whether recording the error succeeded.
+// Fail will return false if the provided response is invalid.
+func Fail(response http.ResponseWriter, err error) bool {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return false
+	}
+	sharedResponse.fail(err)
+	return true
+}
+
+// NextErr is the same as Next, but it returns the error (recorded with Fail
+// or returned by an http.Handler attached with Handler.HandleErr) that
+// propagated up from the subsequent http.Handlers in the middleware chain.
+// An error returned by NextErr is considered handled, so it does not
+// propagate any further unless the caller returns it or calls Fail again.
+//
+// NextErr will return ErrNoNext if no subsequent http.Handler is available or
+// if the response is invalid. It will return nil if the subsequent
+// http.Handlers succeeded.
+func NextErr(response http.ResponseWriter, request *http.Request) error {
+	ok, err := nextErr(response, request)
+	if !ok {
+		return ErrNoNext
+	}
+	return err
+}
+
+// nextErr calls Next and returns any error that propagated up from the
+// subsequent http.Handlers. An error recorded before Next was called remains
+// recorded.
+func nextErr(response http.ResponseWriter, request *http.Request) (bool, error) {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return false, nil
+	}
+	prevErr := sharedResponse.failure()
+	sharedResponse.fail(nil)
+	ok = Next(response, request)
+	err := sharedResponse.failure()
+	sharedResponse.fail(prevErr)
+	return ok, err
+}
+
+func (s *state) failure() error {
+	return s.err
+}
+
+func (s *state) fail(err error) {
+	s.err = err
+}
@@ -0,0 +1,169 @@
+package infuse_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+var errorFixture = `
+start first
+attempting next for first
+start second
+attempting next for second
+start failing
+end failing
+finished next for second
+end second
+finished next for first
+end first
+handled: some error`
+
+func TestOnError(t *testing.T) {
+	handler := infuse.New().OnError(renderError)
+	handler = handler.HandleFunc(buildHandler("first", 1))
+	handler = handler.HandleErr(buildErrHandler("second"))
+	handler = handler.HandleErr(func(response http.ResponseWriter, _ *http.Request) error {
+		fmt.Fprintln(response, "start failing")
+		defer fmt.Fprintln(response, "end failing")
+		return errors.New("some error")
+	})
+	testHandlerResponse(t, serve(handler), errorFixture)
+}
+
+var nextErrFixture = `
+start first
+recovered: some error
+end first`
+
+func TestNextErr(t *testing.T) {
+	handler := infuse.New().OnError(renderError)
+	handler = handler.HandleErr(func(response http.ResponseWriter, request *http.Request) error {
+		fmt.Fprintln(response, "start first")
+		if err := infuse.NextErr(response, request); err != nil {
+			fmt.Fprintf(response, "recovered: %s\n", err)
+		}
+		fmt.Fprintln(response, "end first")
+		return nil
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		infuse.Fail(response, errors.New("some error"))
+	})
+	testHandlerResponse(t, serve(handler), nextErrFixture)
+}
+
+func TestNextErrWithoutNext(t *testing.T) {
+	handler := infuse.New().HandleErr(func(response http.ResponseWriter, request *http.Request) error {
+		return infuse.NextErr(response, request)
+	})
+	handler = infuse.New().OnError(renderError).Handle(handler)
+	testHandlerResponse(t, serve(handler), "handled: infuse: no next handler")
+}
+
+func TestUnhandledError(t *testing.T) {
+	handler := infuse.New().HandleErr(func(response http.ResponseWriter, _ *http.Request) error {
+		return errors.New("some error")
+	})
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+	if response.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d.", response.Code)
+	}
+	testHandlerResponse(t, response.Body.String(), "Internal Server Error")
+}
+
+func TestUnhandledErrorAfterWrite(t *testing.T) {
+	handler := infuse.New().HandleErr(func(response http.ResponseWriter, _ *http.Request) error {
+		response.WriteHeader(http.StatusCreated)
+		fmt.Fprint(response, "partial")
+		return errors.New("some error")
+	})
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+	if response.Code != http.StatusCreated || response.Body.String() != "partial" {
+		t.Fatalf("Expected partial response to be unchanged, got: %d %q", response.Code, response.Body.String())
+	}
+}
+
+func TestUnhandledErrorRunsHooks(t *testing.T) {
+	hookStatus := 0
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.BeforeWrite(response, func(header http.Header, status int) {
+			header.Set("X-Hook", "hooked")
+			hookStatus = status
+		})
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleErr(func(http.ResponseWriter, *http.Request) error {
+		return errors.New("some error")
+	})
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+	if response.Code != http.StatusInternalServerError || response.Header().Get("X-Hook") != "hooked" {
+		t.Fatalf("Expected 500 with hook header, got: %d %v", response.Code, response.Header())
+	}
+	if hookStatus != http.StatusInternalServerError {
+		t.Fatalf("Expected hook to see status 500, got %d.", hookStatus)
+	}
+}
+
+var nestedErrorFixture = `
+start outer
+attempting next for outer
+start inner
+attempting next for inner
+finished next for inner
+end inner
+finished next for outer
+end outer
+handled: inner error`
+
+func TestNestedHandlerErrors(t *testing.T) {
+	inner := infuse.New().HandleErr(buildErrHandler("inner"))
+	inner = inner.HandleErr(func(http.ResponseWriter, *http.Request) error {
+		return errors.New("inner error")
+	})
+
+	handler := infuse.New().OnError(renderError)
+	handler = handler.HandleErr(buildErrHandler("outer"))
+	handler = handler.Handle(inner)
+	testHandlerResponse(t, serve(handler), nestedErrorFixture)
+}
+
+func TestStackedHandlerError(t *testing.T) {
+	failing := infuse.New().HandleErr(func(http.ResponseWriter, *http.Request) error {
+		return errors.New("inner error")
+	})
+	handler := infuse.New().Stack(failing)
+	handler = handler.HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(response, "downstream")
+	})
+	testHandlerResponse(t, serve(handler), "Internal Server Error")
+
+	handler = infuse.New().OnError(renderError).Stack(failing).HandleFunc(buildHandler("never", 0))
+	testHandlerResponse(t, serve(handler), "handled: inner error")
+}
+
+func TestInvalidResponseForErrors(t *testing.T) {
+	if ok := infuse.Fail(nil, errors.New("some error")); ok {
+		t.Fatal("Expected failure to record error on invalid response.")
+	}
+	if err := infuse.NextErr(nil, &http.Request{}); err != infuse.ErrNoNext {
+		t.Fatalf("Expected ErrNoNext from invalid response, got %v.", err)
+	}
+}
+
+func buildErrHandler(name string) func(http.ResponseWriter, *http.Request) error {
+	return func(response http.ResponseWriter, request *http.Request) error {
+		buildHandler(name, 1)(response, request)
+		return nil
+	}
+}
+
+func renderError(response http.ResponseWriter, _ *http.Request, err error) {
+	fmt.Fprintf(response, "handled: %s\n", err)
+}
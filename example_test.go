@@ -29,6 +29,20 @@ func Example() {
 	// Permission Denied
 }
 
+func ExampleHandler_Then() {
+	application := http.NewServeMux()
+	application.HandleFunc("/hello", userGreeting)
+	server := httptest.NewServer(infuse.New().HandleFunc(basicAuth).Then(application))
+	defer server.Close()
+
+	doRequest(server.URL+"/hello", "bob", "1234")
+	doRequest(server.URL+"/hello", "intruder", "guess")
+
+	// Output:
+	// Hello bob!
+	// Permission Denied
+}
+
 func userGreeting(response http.ResponseWriter, request *http.Request) {
 	username := infuse.Get(response).(string)
 	fmt.Fprintf(response, "Hello %s!", username)
@@ -1,5 +1,7 @@
 package infuse
 
+//go:generate go run gen_extensions.go
+
 import (
 	"bufio"
 	"io"
@@ -7,38 +9,95 @@ import (
 	"net/http"
 )
 
-type stringWriter interface {
-	WriteString(s string) (n int, err error)
+// An extension is a set of optional http.ResponseWriter interfaces.
+type extension int
+
+const (
+	closeNotifierExtension extension = 1 << iota
+	flusherExtension
+	hijackerExtension
+	readerFromExtension
+	stringWriterExtension
+	pusherExtension
+)
+
+// extensions returns the set of optional interfaces implemented by the
+// provided response.
+func extensions(response http.ResponseWriter) extension {
+	var set extension
+	if _, ok := response.(http.CloseNotifier); ok {
+		set |= closeNotifierExtension
+	}
+	if _, ok := response.(http.Flusher); ok {
+		set |= flusherExtension
+	}
+	if _, ok := response.(http.Hijacker); ok {
+		set |= hijackerExtension
+	}
+	if _, ok := response.(io.ReaderFrom); ok {
+		set |= readerFromExtension
+	}
+	if _, ok := response.(io.StringWriter); ok {
+		set |= stringWriterExtension
+	}
+	if _, ok := response.(http.Pusher); ok {
+		set |= pusherExtension
+	}
+	return set
 }
 
-type fullResponse struct {
+type closeNotifier struct {
 	*layeredResponse
 }
 
-func (f *fullResponse) CloseNotify() <-chan bool {
-	return f.ResponseWriter.(http.CloseNotifier).CloseNotify()
+func (c closeNotifier) CloseNotify() <-chan bool {
+	return c.ResponseWriter.(http.CloseNotifier).CloseNotify()
 }
 
-func (f *fullResponse) Flush() {
+type flusher struct {
+	*layeredResponse
+}
+
+func (f flusher) Flush() {
+	f.writingHeader(http.StatusOK)
 	f.ResponseWriter.(http.Flusher).Flush()
 }
 
-func (f *fullResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return f.ResponseWriter.(http.Hijacker).Hijack()
+type hijacker struct {
+	*layeredResponse
 }
 
-func (f *fullResponse) ReadFrom(src io.Reader) (n int64, err error) {
-	return f.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacking()
+	return h.ResponseWriter.(http.Hijacker).Hijack()
 }
 
-func (f *fullResponse) WriteString(s string) (n int, err error) {
-	return f.ResponseWriter.(stringWriter).WriteString(s)
+type readerFrom struct {
+	*layeredResponse
 }
 
-type flushableResponse struct {
+func (r readerFrom) ReadFrom(src io.Reader) (n int64, err error) {
+	r.writingHeader(http.StatusOK)
+	n, err = r.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	r.wrote(n)
+	return n, err
+}
+
+type stringWriter struct {
 	*layeredResponse
 }
 
-func (f *flushableResponse) Flush() {
-	f.ResponseWriter.(http.Flusher).Flush()
+func (s stringWriter) WriteString(str string) (n int, err error) {
+	s.writingHeader(http.StatusOK)
+	n, err = s.ResponseWriter.(io.StringWriter).WriteString(str)
+	s.wrote(int64(n))
+	return n, err
+}
+
+type pusher struct {
+	*layeredResponse
+}
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.ResponseWriter.(http.Pusher).Push(target, opts)
 }
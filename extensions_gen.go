@@ -0,0 +1,459 @@
+// Code generated by gen_extensions.go; DO NOT EDIT.
+
+package infuse
+
+import "net/http"
+
+// extendResponse returns the *layeredResponse extended with the methods
+// of every extension in the provided set.
+func extendResponse(l *layeredResponse, set extension) http.ResponseWriter {
+	switch set {
+	case 0:
+		return l
+	case closeNotifierExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+		}{l, closeNotifier{l}}
+	case flusherExtension:
+		return struct {
+			*layeredResponse
+			flusher
+		}{l, flusher{l}}
+	case closeNotifierExtension | flusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+		}{l, closeNotifier{l}, flusher{l}}
+	case hijackerExtension:
+		return struct {
+			*layeredResponse
+			hijacker
+		}{l, hijacker{l}}
+	case closeNotifierExtension | hijackerExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			hijacker
+		}{l, closeNotifier{l}, hijacker{l}}
+	case flusherExtension | hijackerExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			hijacker
+		}{l, flusher{l}, hijacker{l}}
+	case closeNotifierExtension | flusherExtension | hijackerExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			hijacker
+		}{l, closeNotifier{l}, flusher{l}, hijacker{l}}
+	case readerFromExtension:
+		return struct {
+			*layeredResponse
+			readerFrom
+		}{l, readerFrom{l}}
+	case closeNotifierExtension | readerFromExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			readerFrom
+		}{l, closeNotifier{l}, readerFrom{l}}
+	case flusherExtension | readerFromExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			readerFrom
+		}{l, flusher{l}, readerFrom{l}}
+	case closeNotifierExtension | flusherExtension | readerFromExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			readerFrom
+		}{l, closeNotifier{l}, flusher{l}, readerFrom{l}}
+	case hijackerExtension | readerFromExtension:
+		return struct {
+			*layeredResponse
+			hijacker
+			readerFrom
+		}{l, hijacker{l}, readerFrom{l}}
+	case closeNotifierExtension | hijackerExtension | readerFromExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			hijacker
+			readerFrom
+		}{l, closeNotifier{l}, hijacker{l}, readerFrom{l}}
+	case flusherExtension | hijackerExtension | readerFromExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			hijacker
+			readerFrom
+		}{l, flusher{l}, hijacker{l}, readerFrom{l}}
+	case closeNotifierExtension | flusherExtension | hijackerExtension | readerFromExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			hijacker
+			readerFrom
+		}{l, closeNotifier{l}, flusher{l}, hijacker{l}, readerFrom{l}}
+	case stringWriterExtension:
+		return struct {
+			*layeredResponse
+			stringWriter
+		}{l, stringWriter{l}}
+	case closeNotifierExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			stringWriter
+		}{l, closeNotifier{l}, stringWriter{l}}
+	case flusherExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			stringWriter
+		}{l, flusher{l}, stringWriter{l}}
+	case closeNotifierExtension | flusherExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			stringWriter
+		}{l, closeNotifier{l}, flusher{l}, stringWriter{l}}
+	case hijackerExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			hijacker
+			stringWriter
+		}{l, hijacker{l}, stringWriter{l}}
+	case closeNotifierExtension | hijackerExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			hijacker
+			stringWriter
+		}{l, closeNotifier{l}, hijacker{l}, stringWriter{l}}
+	case flusherExtension | hijackerExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			hijacker
+			stringWriter
+		}{l, flusher{l}, hijacker{l}, stringWriter{l}}
+	case closeNotifierExtension | flusherExtension | hijackerExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			hijacker
+			stringWriter
+		}{l, closeNotifier{l}, flusher{l}, hijacker{l}, stringWriter{l}}
+	case readerFromExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			readerFrom
+			stringWriter
+		}{l, readerFrom{l}, stringWriter{l}}
+	case closeNotifierExtension | readerFromExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			readerFrom
+			stringWriter
+		}{l, closeNotifier{l}, readerFrom{l}, stringWriter{l}}
+	case flusherExtension | readerFromExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			readerFrom
+			stringWriter
+		}{l, flusher{l}, readerFrom{l}, stringWriter{l}}
+	case closeNotifierExtension | flusherExtension | readerFromExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			readerFrom
+			stringWriter
+		}{l, closeNotifier{l}, flusher{l}, readerFrom{l}, stringWriter{l}}
+	case hijackerExtension | readerFromExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			hijacker
+			readerFrom
+			stringWriter
+		}{l, hijacker{l}, readerFrom{l}, stringWriter{l}}
+	case closeNotifierExtension | hijackerExtension | readerFromExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			hijacker
+			readerFrom
+			stringWriter
+		}{l, closeNotifier{l}, hijacker{l}, readerFrom{l}, stringWriter{l}}
+	case flusherExtension | hijackerExtension | readerFromExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			hijacker
+			readerFrom
+			stringWriter
+		}{l, flusher{l}, hijacker{l}, readerFrom{l}, stringWriter{l}}
+	case closeNotifierExtension | flusherExtension | hijackerExtension | readerFromExtension | stringWriterExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			hijacker
+			readerFrom
+			stringWriter
+		}{l, closeNotifier{l}, flusher{l}, hijacker{l}, readerFrom{l}, stringWriter{l}}
+	case pusherExtension:
+		return struct {
+			*layeredResponse
+			pusher
+		}{l, pusher{l}}
+	case closeNotifierExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			pusher
+		}{l, closeNotifier{l}, pusher{l}}
+	case flusherExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			pusher
+		}{l, flusher{l}, pusher{l}}
+	case closeNotifierExtension | flusherExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			pusher
+		}{l, closeNotifier{l}, flusher{l}, pusher{l}}
+	case hijackerExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			hijacker
+			pusher
+		}{l, hijacker{l}, pusher{l}}
+	case closeNotifierExtension | hijackerExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			hijacker
+			pusher
+		}{l, closeNotifier{l}, hijacker{l}, pusher{l}}
+	case flusherExtension | hijackerExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			hijacker
+			pusher
+		}{l, flusher{l}, hijacker{l}, pusher{l}}
+	case closeNotifierExtension | flusherExtension | hijackerExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			hijacker
+			pusher
+		}{l, closeNotifier{l}, flusher{l}, hijacker{l}, pusher{l}}
+	case readerFromExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			readerFrom
+			pusher
+		}{l, readerFrom{l}, pusher{l}}
+	case closeNotifierExtension | readerFromExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			readerFrom
+			pusher
+		}{l, closeNotifier{l}, readerFrom{l}, pusher{l}}
+	case flusherExtension | readerFromExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			readerFrom
+			pusher
+		}{l, flusher{l}, readerFrom{l}, pusher{l}}
+	case closeNotifierExtension | flusherExtension | readerFromExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			readerFrom
+			pusher
+		}{l, closeNotifier{l}, flusher{l}, readerFrom{l}, pusher{l}}
+	case hijackerExtension | readerFromExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			hijacker
+			readerFrom
+			pusher
+		}{l, hijacker{l}, readerFrom{l}, pusher{l}}
+	case closeNotifierExtension | hijackerExtension | readerFromExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			hijacker
+			readerFrom
+			pusher
+		}{l, closeNotifier{l}, hijacker{l}, readerFrom{l}, pusher{l}}
+	case flusherExtension | hijackerExtension | readerFromExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			hijacker
+			readerFrom
+			pusher
+		}{l, flusher{l}, hijacker{l}, readerFrom{l}, pusher{l}}
+	case closeNotifierExtension | flusherExtension | hijackerExtension | readerFromExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			hijacker
+			readerFrom
+			pusher
+		}{l, closeNotifier{l}, flusher{l}, hijacker{l}, readerFrom{l}, pusher{l}}
+	case stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			stringWriter
+			pusher
+		}{l, stringWriter{l}, pusher{l}}
+	case closeNotifierExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			stringWriter
+			pusher
+		}{l, closeNotifier{l}, stringWriter{l}, pusher{l}}
+	case flusherExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			stringWriter
+			pusher
+		}{l, flusher{l}, stringWriter{l}, pusher{l}}
+	case closeNotifierExtension | flusherExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			stringWriter
+			pusher
+		}{l, closeNotifier{l}, flusher{l}, stringWriter{l}, pusher{l}}
+	case hijackerExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			hijacker
+			stringWriter
+			pusher
+		}{l, hijacker{l}, stringWriter{l}, pusher{l}}
+	case closeNotifierExtension | hijackerExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			hijacker
+			stringWriter
+			pusher
+		}{l, closeNotifier{l}, hijacker{l}, stringWriter{l}, pusher{l}}
+	case flusherExtension | hijackerExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			hijacker
+			stringWriter
+			pusher
+		}{l, flusher{l}, hijacker{l}, stringWriter{l}, pusher{l}}
+	case closeNotifierExtension | flusherExtension | hijackerExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			hijacker
+			stringWriter
+			pusher
+		}{l, closeNotifier{l}, flusher{l}, hijacker{l}, stringWriter{l}, pusher{l}}
+	case readerFromExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			readerFrom
+			stringWriter
+			pusher
+		}{l, readerFrom{l}, stringWriter{l}, pusher{l}}
+	case closeNotifierExtension | readerFromExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			readerFrom
+			stringWriter
+			pusher
+		}{l, closeNotifier{l}, readerFrom{l}, stringWriter{l}, pusher{l}}
+	case flusherExtension | readerFromExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			readerFrom
+			stringWriter
+			pusher
+		}{l, flusher{l}, readerFrom{l}, stringWriter{l}, pusher{l}}
+	case closeNotifierExtension | flusherExtension | readerFromExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			readerFrom
+			stringWriter
+			pusher
+		}{l, closeNotifier{l}, flusher{l}, readerFrom{l}, stringWriter{l}, pusher{l}}
+	case hijackerExtension | readerFromExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			hijacker
+			readerFrom
+			stringWriter
+			pusher
+		}{l, hijacker{l}, readerFrom{l}, stringWriter{l}, pusher{l}}
+	case closeNotifierExtension | hijackerExtension | readerFromExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			hijacker
+			readerFrom
+			stringWriter
+			pusher
+		}{l, closeNotifier{l}, hijacker{l}, readerFrom{l}, stringWriter{l}, pusher{l}}
+	case flusherExtension | hijackerExtension | readerFromExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			flusher
+			hijacker
+			readerFrom
+			stringWriter
+			pusher
+		}{l, flusher{l}, hijacker{l}, readerFrom{l}, stringWriter{l}, pusher{l}}
+	case closeNotifierExtension | flusherExtension | hijackerExtension | readerFromExtension | stringWriterExtension | pusherExtension:
+		return struct {
+			*layeredResponse
+			closeNotifier
+			flusher
+			hijacker
+			readerFrom
+			stringWriter
+			pusher
+		}{l, closeNotifier{l}, flusher{l}, hijacker{l}, readerFrom{l}, stringWriter{l}, pusher{l}}
+	}
+	return l
+}
@@ -20,17 +20,25 @@ CloseNotify called
 Flush called
 Hijack called
 ReadFrom called with some data
-WriteString called with some string`
+WriteString called with some string
+Push called with /some/target`
 
 var flushableResponseMethodsFixture = `
 attempting extensions
-Flush called`
+Flush called
+some string`
+
+var partialResponseMethodsFixture = `
+attempting extensions
+Hijack called
+ReadFrom called with some data`
 
 func TestResponseMethods(t *testing.T) {
 	handler := infuse.New().HandleFunc(responseMethodsHandler)
 	testHandlerResponse(t, serveExtended(handler), extendedResponseMethodsFixture)
 	testHandlerResponse(t, serveFlushable(handler), flushableResponseMethodsFixture)
 	testHandlerResponse(t, serveLimited(handler), "attempting extensions")
+	testHandlerResponse(t, servePartial(handler), partialResponseMethodsFixture)
 }
 
 func responseMethodsHandler(response http.ResponseWriter, _ *http.Request) {
@@ -48,13 +56,12 @@ func responseMethodsHandler(response http.ResponseWriter, _ *http.Request) {
 	if _, ok := response.(io.ReaderFrom); ok {
 		response.(io.ReaderFrom).ReadFrom(strings.NewReader("some data"))
 	}
-	if _, ok := response.(stringWriter); ok {
-		response.(stringWriter).WriteString("some string")
+	if _, ok := response.(io.StringWriter); ok {
+		response.(io.StringWriter).WriteString("some string")
+	}
+	if _, ok := response.(http.Pusher); ok {
+		response.(http.Pusher).Push("/some/target", nil)
 	}
-}
-
-type stringWriter interface {
-	WriteString(s string) (n int, err error)
 }
 
 func serveExtended(handler http.Handler) string {
@@ -75,6 +82,13 @@ func serveLimited(handler http.Handler) string {
 	return response.ResponseWriter.(*httptest.ResponseRecorder).Body.String()
 }
 
+func servePartial(handler http.Handler) string {
+	recorder := httptest.NewRecorder()
+	response := &partialResponse{&limitedResponse{recorder}}
+	handler.ServeHTTP(response, &http.Request{})
+	return recorder.Body.String()
+}
+
 type extendedResponse struct {
 	*httptest.ResponseRecorder
 }
@@ -106,6 +120,11 @@ func (e *extendedResponse) WriteString(s string) (n int, err error) {
 	return 0, nil
 }
 
+func (e *extendedResponse) Push(target string, _ *http.PushOptions) error {
+	fmt.Fprintf(e, "Push called with %s\n", target)
+	return nil
+}
+
 type flushableResponse struct {
 	*httptest.ResponseRecorder
 }
@@ -117,3 +136,20 @@ func (f *flushableResponse) Flush() {
 type limitedResponse struct {
 	http.ResponseWriter
 }
+
+type partialResponse struct {
+	*limitedResponse
+}
+
+func (p *partialResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	fmt.Fprintln(p, "Hijack called")
+	return nil, nil, nil
+}
+
+func (p *partialResponse) ReadFrom(src io.Reader) (n int64, err error) {
+	srcStr, err := ioutil.ReadAll(src)
+	if err == nil {
+		fmt.Fprintf(p, "ReadFrom called with %s\n", srcStr)
+	}
+	return 0, nil
+}
@@ -0,0 +1,61 @@
+//go:build ignore
+// +build ignore
+
+// This program generates extensions_gen.go. Run it with go generate.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"strings"
+)
+
+var extensions = []string{
+	"closeNotifier",
+	"flusher",
+	"hijacker",
+	"readerFrom",
+	"stringWriter",
+	"pusher",
+}
+
+func main() {
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "// Code generated by gen_extensions.go; DO NOT EDIT.")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "package infuse")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, `import "net/http"`)
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "// extendResponse returns the *layeredResponse extended with the methods")
+	fmt.Fprintln(buf, "// of every extension in the provided set.")
+	fmt.Fprintln(buf, "func extendResponse(l *layeredResponse, set extension) http.ResponseWriter {")
+	fmt.Fprintln(buf, "switch set {")
+	fmt.Fprintln(buf, "case 0:")
+	fmt.Fprintln(buf, "return l")
+	for set := 1; set < 1<<len(extensions); set++ {
+		var names, constants, values []string
+		for i, name := range extensions {
+			if set&(1<<i) != 0 {
+				names = append(names, name)
+				constants = append(constants, name+"Extension")
+				values = append(values, name+"{l}")
+			}
+		}
+		fmt.Fprintf(buf, "case %s:\n", strings.Join(constants, " | "))
+		fmt.Fprintf(buf, "return struct {\n*layeredResponse\n%s\n}{l, %s}\n", strings.Join(names, "\n"), strings.Join(values, ", "))
+	}
+	fmt.Fprintln(buf, "}")
+	fmt.Fprintln(buf, "return l")
+	fmt.Fprintln(buf, "}")
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile("extensions_gen.go", source, 0644); err != nil {
+		panic(err)
+	}
+}
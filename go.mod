@@ -0,0 +1,3 @@
+module github.com/sclevine/infuse
+
+go 1.18
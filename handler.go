@@ -3,6 +3,15 @@
 // the Go standard library, supports flexible chaining, and provides a shared
 // context between middleware handlers without relying on global state, locks,
 // or shared closures.
+//
+// An infuse.Handler may be served concurrently, but the http.Handlers
+// attached to it share the state of each request, which is not safe for
+// concurrent use. Next and the other functions that take the response
+// provided to an http.Handler must only be called while that http.Handler
+// is being served. They may be called from another goroutine, as long as the
+// http.Handler waits for the call to return. Middleware that serves the rest
+// of the middleware chain in the background after it returns, such as
+// http.TimeoutHandler attached with Handler.Wrap, is not supported.
 package infuse
 
 import "net/http"
@@ -37,6 +46,148 @@ type Handler interface {
 	// instead of an http.Handler.
 	StackFunc(handler func(http.ResponseWriter, *http.Request)) Handler
 
+	// HandleNamed is the same as Handle, but the attached http.Handler is
+	// identified by the provided name when the infuse.Handler is described
+	// by infuse.Layers.
+	HandleNamed(name string, handler http.Handler) Handler
+
+	// StackNamed is the same as Stack, but the attached http.Handler is
+	// identified by the provided name when the infuse.Handler is described
+	// by infuse.Layers.
+	StackNamed(name string, handler http.Handler) Handler
+
+	// HandleErr has the same behavior as HandleFunc, but the provided handler
+	// function returns an error. A non-nil error is recorded with
+	// infuse.Fail, so that it propagates up the middleware chain to the
+	// nearest error handler attached with OnError.
+	HandleErr(handler func(http.ResponseWriter, *http.Request) error) Handler
+
+	// OnError returns a copy of the current infuse.Handler with the provided
+	// error handler attached. The error handler is called with any error
+	// that propagates up from the http.Handlers attached after it (see
+	// infuse.Fail). Errors from http.Handlers attached before it are not
+	// handled by it.
+	//
+	// Errors that are not handled by an error handler result in a 500
+	// Internal Server Error response, unless the infuse.Handler is nested
+	// in another infuse.Handler, in which case they propagate to the outer
+	// infuse.Handler.
+	OnError(handler func(http.ResponseWriter, *http.Request, error)) Handler
+
+	// Recover returns a copy of the current infuse.Handler with a handler
+	// attached that recovers from panics in the http.Handlers attached
+	// after it. The provided reporter is called with a description of each
+	// recovered panic, including the attached http.Handler that panicked and
+	// the stack trace. If the reporter is nil, panics are logged with the
+	// log package.
+	//
+	// After reporting a panic, Recover responds with a 500 Internal Server
+	// Error, unless the response headers were already written. Panics with
+	// the value http.ErrAbortHandler are not recovered.
+	Recover(reporter func(*http.Request, *Panic)) Handler
+
+	// Observe returns a copy of the current infuse.Handler with the provided
+	// infuse.Observer attached. The Observer is notified each time one of the
+	// http.Handlers attached after it is entered and exited.
+	Observe(observer Observer) Handler
+
+	// When returns a copy of the current infuse.Handler with the provided
+	// branch attached, such that the branch is only served for requests
+	// that match the provided predicate. Unlike an infuse.Handler attached
+	// with Handle or Stack, the http.Handlers attached to the branch share
+	// the context values of the current infuse.Handler, and a call to
+	// infuse.Next from the last http.Handler attached to the branch serves
+	// the http.Handlers attached after the branch (see infuse.Branch). For
+	// requests that do not match, the branch is skipped entirely.
+	When(predicate func(*http.Request) bool, branch Handler) Handler
+
+	// Unless is the same as When, but the branch is only served for
+	// requests that do not match the provided predicate.
+	Unless(predicate func(*http.Request) bool, branch Handler) Handler
+
+	// HandleNext has the same behavior as HandleFunc, but the provided
+	// handler function is also passed a next function, which serves the
+	// http.Handlers attached after it. Calling next is the same as calling
+	// infuse.Next, or infuse.NextWith if next is called with a different
+	// http.ResponseWriter. This allows negroni-style middleware to be
+	// attached unchanged.
+	HandleNext(handler func(http.ResponseWriter, *http.Request, http.HandlerFunc)) Handler
+
+	// Wrap returns a copy of the current infuse.Handler with the provided
+	// standard middleware function attached. The next http.Handler provided
+	// to the middleware function serves the http.Handlers attached after it,
+	// as if infuse.Next were called. If the middleware calls the next
+	// http.Handler with a different http.ResponseWriter, the subsequent
+	// http.Handlers write to it, as if it were passed to infuse.NextWith.
+	//
+	// The middleware function is called once, when it is attached. To find
+	// the middleware chain, the next http.Handler relies on either the
+	// http.ResponseWriter or the request that the middleware passes to it.
+	// The middleware must therefore either pass a request derived from the
+	// request it was provided with, so that the request context is kept, or
+	// pass an http.ResponseWriter with an Unwrap method that returns the
+	// response it was provided with. Otherwise, the next http.Handler does
+	// not serve the subsequent http.Handlers.
+	Wrap(middleware func(http.Handler) http.Handler) Handler
+
+	// Parallel returns a copy of the current infuse.Handler with a handler
+	// attached that serves the provided http.Handlers concurrently, stores
+	// their Recordings as a context value, and then calls infuse.Next (see
+	// infuse.Parallel). An http.Handler attached after it can retrieve the
+	// Recordings with infuse.Recordings.
+	Parallel(handlers ...http.Handler) Handler
+
+	// Prepend returns a copy of the current infuse.Handler with the provided
+	// http.Handler attached before all of the other http.Handlers, so that it
+	// is called first when the infuse.Handler is served. The http.Handler is
+	// attached as if by Handle.
+	Prepend(handler http.Handler) Handler
+
+	// InsertBefore returns a copy of the current infuse.Handler with the
+	// provided http.Handler attached immediately before the first
+	// http.Handler that was attached with the provided name (see
+	// HandleNamed). The http.Handler is attached as if by Handle. If no
+	// http.Handler has the provided name, the infuse.Handler is returned
+	// unchanged.
+	InsertBefore(name string, handler http.Handler) Handler
+
+	// Replace returns a copy of the current infuse.Handler in which the
+	// first http.Handler that was attached with the provided name is
+	// replaced by the provided http.Handler. The replacement is attached as
+	// if by HandleNamed with the same name. If no http.Handler has the
+	// provided name, the infuse.Handler is returned unchanged.
+	Replace(name string, handler http.Handler) Handler
+
+	// Without returns a copy of the current infuse.Handler without the first
+	// http.Handler that was attached with the provided name. If no
+	// http.Handler has the provided name, the infuse.Handler is returned
+	// unchanged.
+	//
+	// An empty name does not match http.Handlers attached without a name,
+	// so InsertBefore, Replace, and Without return the infuse.Handler
+	// unchanged if they are provided with an empty name.
+	//
+	// Like the other methods of an infuse.Handler, Prepend, InsertBefore,
+	// Replace, and Without do not modify the current infuse.Handler. The
+	// http.Handlers attached before the change are shared with it.
+	Without(name string) Handler
+
+	// Then returns a copy of the current infuse.Handler that is finalized
+	// with the provided terminal http.Handler. The terminal http.Handler is
+	// served when the last http.Handler attached to the infuse.Handler calls
+	// infuse.Next, so that the infuse.Handler can be used as middleware in
+	// front of an existing application handler. Since no further
+	// http.Handlers may be attached after the terminal http.Handler, the
+	// result is an http.Handler.
+	//
+	// The terminal http.Handler does not need to call infuse.Next. If it
+	// does, infuse.Next returns false.
+	Then(handler http.Handler) http.Handler
+
+	// ThenFunc is the same as Then, but it takes a handler function instead
+	// of an http.Handler.
+	ThenFunc(handler func(http.ResponseWriter, *http.Request)) http.Handler
+
 	// ServeHTTP serves the infuse.Handler, starting with the first
 	// http.Handler attached.
 	ServeHTTP(response http.ResponseWriter, request *http.Request)
@@ -47,15 +198,51 @@ type Handler interface {
 // The provided response must be same http.ResponseWriter provided to the
 // current http.Handler in the chain.
 //
+// The provided response may also be a wrapper around that
+// http.ResponseWriter, as long as the wrapper (and any wrappers it wraps)
+// provides an Unwrap method that returns the wrapped http.ResponseWriter:
+//
+//   Unwrap() http.ResponseWriter
+//
+// In that case, the subsequent http.Handlers write to the wrapper, as if it
+// were passed to NextWith.
+//
 // The boolean return value indicates whether the call succeeded. Next will
 // return false if no subsequent http.Handler is available or if the response
 // is invalid.
 //
+// Next must be called before the current http.Handler returns. It may be
+// called from another goroutine, as long as the current http.Handler waits
+// for it to return.
+//
 // Calling Next multiple times in the same handler will call all remaining
-// http.Handlers in the middleware chain each time.
+// http.Handlers in the middleware chain each time. To discard the responses
+// of earlier attempts, use NextCapture.
 func Next(response http.ResponseWriter, request *http.Request) bool {
-	sharedResponse, ok := response.(infuseResponse)
-	return ok && sharedResponse.next(request)
+	sharedResponse, ok := find(response)
+	if !ok {
+		return false
+	}
+	if _, ok := response.(infuseResponse); !ok {
+		return sharedResponse.nextWith(response, request)
+	}
+	return sharedResponse.next(request)
+}
+
+// NextWith is the same as Next, but the subsequent http.Handlers in the
+// middleware chain write to the provided replacement http.ResponseWriter
+// instead of the current response. This allows middleware to wrap the
+// response (e.g., to compress or capture the response body) and then
+// continue the chain. The replacement is typically a wrapper around the
+// current response.
+//
+// The context values and position in the middleware chain are the same as
+// they would be for Next, and the response provided to the subsequent
+// http.Handlers may be type-asserted into any of the extra interfaces (such
+// as http.Flusher) that the replacement implements.
+func NextWith(response, replacement http.ResponseWriter, request *http.Request) bool {
+	sharedResponse, ok := find(response)
+	return ok && sharedResponse.nextWith(replacement, request)
 }
 
 // New returns a new infuse.Handler.
@@ -66,25 +253,127 @@ func New() Handler {
 type layer struct {
 	handler http.Handler
 	prev    *layer
+
+	name     string
+	kind     Kind
+	attached http.Handler
+	source   string
+
+	chain []*layer
+}
+
+// attach returns a copy of the current infuse.Handler with a new layer that
+// serves the provided handler. The attached http.Handler is the handler that
+// was provided to the exported method that called attach, which is also
+// used to determine the source location of the layer.
+func (l *layer) attach(name string, kind Kind, attached, handler http.Handler) Handler {
+	if _, ok := attached.(*layer); ok && kind == KindHandle {
+		kind = KindNested
+	}
+	added := &layer{handler: handler, name: name, kind: kind, attached: attached, source: caller(2)}
+	return added.link(l)
+}
+
+// link sets the layer attached before the current layer and records the
+// flattened chain of layers, so that it is not computed each time the
+// infuse.Handler is served.
+func (l *layer) link(prev *layer) *layer {
+	l.prev = prev
+	l.chain = append([]*layer{l}, prev.flatten()...)
+	return l
 }
 
 func (l *layer) Handle(handler http.Handler) Handler {
-	return &layer{handler, l}
+	return l.attach("", KindHandle, handler, handler)
 }
 
 func (l *layer) HandleFunc(handler func(http.ResponseWriter, *http.Request)) Handler {
-	return l.Handle(http.HandlerFunc(handler))
+	return l.attach("", KindHandle, http.HandlerFunc(handler), http.HandlerFunc(handler))
+}
+
+func (l *layer) HandleNamed(name string, handler http.Handler) Handler {
+	return l.attach(name, KindHandle, handler, handler)
+}
+
+func (l *layer) HandleNext(handler func(http.ResponseWriter, *http.Request, http.HandlerFunc)) Handler {
+	nextHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		handler(response, request, func(next http.ResponseWriter, request *http.Request) {
+			continueChain(response, next, request)
+		})
+	})
+	return l.attach("", KindHandleNext, nextHandler, nextHandler)
+}
+
+// continueChain continues the middleware chain from the current response,
+// with the subsequent http.Handlers writing to next if it differs from the
+// current response.
+func continueChain(current, next http.ResponseWriter, request *http.Request) {
+	if current == next {
+		Next(current, request)
+		return
+	}
+	NextWith(current, next, request)
 }
 
 func (l *layer) Stack(handler http.Handler) Handler {
-	return l.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+	return l.attach("", KindStack, handler, stack(handler))
+}
+
+func (l *layer) StackFunc(handler func(http.ResponseWriter, *http.Request)) Handler {
+	return l.attach("", KindStack, http.HandlerFunc(handler), stack(http.HandlerFunc(handler)))
+}
+
+func (l *layer) StackNamed(name string, handler http.Handler) Handler {
+	return l.attach(name, KindStack, handler, stack(handler))
+}
+
+// stack returns an http.Handler that serves the provided handler and then
+// calls Next, unless the provided handler records an error (e.g., a nested
+// infuse.Handler with an unhandled error). In that case, the error propagates
+// up the middleware chain instead.
+func stack(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		sharedResponse, ok := find(response)
+		if !ok {
+			handler.ServeHTTP(response, request)
+			Next(response, request)
+			return
+		}
+		prevErr := sharedResponse.failure()
+		sharedResponse.fail(nil)
 		handler.ServeHTTP(response, request)
+		if sharedResponse.failure() != nil {
+			return
+		}
+		sharedResponse.fail(prevErr)
 		Next(response, request)
 	})
 }
 
-func (l *layer) StackFunc(handler func(http.ResponseWriter, *http.Request)) Handler {
-	return l.Stack(http.HandlerFunc(handler))
+func (l *layer) HandleErr(handler func(http.ResponseWriter, *http.Request) error) Handler {
+	errHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if err := handler(response, request); err != nil {
+			Fail(response, err)
+		}
+	})
+	return l.attach("", KindHandleErr, errHandler, errHandler)
+}
+
+func (l *layer) OnError(handler func(http.ResponseWriter, *http.Request, error)) Handler {
+	errHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if ok, err := nextErr(response, request); ok && err != nil {
+			handler(response, request, err)
+		}
+	})
+	return l.attach("", KindOnError, errHandler, errHandler)
+}
+
+func (l *layer) Then(handler http.Handler) http.Handler {
+	return l.attach("", KindThen, handler, handler)
+}
+
+func (l *layer) ThenFunc(handler func(http.ResponseWriter, *http.Request)) http.Handler {
+	return l.attach("", KindThen, http.HandlerFunc(handler), http.HandlerFunc(handler))
 }
 
 func (l *layer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
@@ -93,9 +382,32 @@ func (l *layer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
 	}
 
 	sharedResponse := newLayeredResponse(response)
-	current := l
-	for ; current.prev != nil; current = current.prev {
-		sharedResponse.layers = append(sharedResponse.layers, current)
+	layers := l.flatten()
+	sharedResponse.layers = layers[:len(layers)-1]
+	sharedResponse.serve(layers[len(layers)-1], request)
+
+	parent := sharedResponse.parent
+	if parent != nil && sharedResponse.isAborted() {
+		parent.abort()
+	}
+	if err := sharedResponse.failure(); err != nil {
+		if parent != nil {
+			parent.fail(err)
+			return
+		}
+		if !sharedResponse.headerWritten() {
+			status := http.StatusInternalServerError
+			http.Error(sharedResponse.contextualResponse, http.StatusText(status), status)
+		}
+	}
+}
+
+// flatten returns the current layer and all of the layers attached before
+// it, in reverse order. The returned slice is shared and must not be
+// modified.
+func (l *layer) flatten() []*layer {
+	if l == nil {
+		return nil
 	}
-	current.handler.ServeHTTP(sharedResponse.extend(), request)
+	return l.chain
 }
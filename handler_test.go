@@ -1,8 +1,10 @@
 package infuse_test
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/sclevine/infuse"
@@ -33,6 +35,44 @@ func TestHandler(t *testing.T) {
 	testHandlerResponse(t, serve(handler), threeHandlerFixture)
 }
 
+func TestThen(t *testing.T) {
+	handler := infuse.New().HandleFunc(buildHandler("first", 1))
+	handler = handler.HandleFunc(buildHandler("second", 1))
+	testHandlerResponse(t, serve(handler.ThenFunc(buildHandler("third", 1))), threeHandlerFixture)
+
+	middleware := infuse.New().HandleFunc(buildHandler("first", 1)).HandleFunc(buildHandler("second", 1))
+	terminal := middleware.Then(http.HandlerFunc(buildHandler("third", 1)))
+	testHandlerResponse(t, serve(terminal), threeHandlerFixture)
+
+	layers := infuse.Layers(terminal)
+	if len(layers) != 3 || layers[2].Kind != infuse.KindThen {
+		t.Fatalf("Expected terminal handler to be described, got: %v", layers)
+	}
+}
+
+func TestHandleNext(t *testing.T) {
+	handler := infuse.New().HandleNext(func(response http.ResponseWriter, request *http.Request, next http.HandlerFunc) {
+		fmt.Fprintln(response, "start first")
+		fmt.Fprintln(response, "attempting next for first")
+		next(response, request)
+		fmt.Fprintln(response, "finished next for first")
+		fmt.Fprintln(response, "end first")
+	})
+	handler = handler.HandleFunc(buildHandler("second", 1))
+	handler = handler.HandleFunc(buildHandler("third", 1))
+	testHandlerResponse(t, serve(handler), threeHandlerFixture)
+}
+
+func TestHandleNextWithResponse(t *testing.T) {
+	handler := infuse.New().HandleNext(func(response http.ResponseWriter, request *http.Request, next http.HandlerFunc) {
+		fmt.Fprintln(response, "start first")
+		next(&upperResponse{ResponseWriter: response}, request)
+		fmt.Fprintln(response, "end first")
+	})
+	handler = handler.HandleFunc(buildHandler("second", 0))
+	testHandlerResponse(t, serve(handler), "start first\nSTART SECOND\nEND SECOND\nend first")
+}
+
 func TestNestedHandlers(t *testing.T) {
 	handler := infuse.New().HandleFunc(buildHandler("third", 1))
 	handler = infuse.New().HandleFunc(buildHandler("second", 1)).Handle(handler)
@@ -183,10 +223,104 @@ func TestPanicRecovery(t *testing.T) {
 	testHandlerResponse(t, serve(handler), panicRecoveryHandlerFixture)
 }
 
+var nextWithFixture = `
+start first
+START SECOND
+ATTEMPTING NEXT FOR SECOND
+START THIRD
+ATTEMPTING NEXT FOR THIRD
+NO NEXT FOR THIRD
+END THIRD
+FINISHED NEXT FOR SECOND
+END SECOND
+flush called: true
+end first`
+
+func TestNextWith(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		fmt.Fprintln(response, "start first")
+		replacement := &upperResponse{ResponseWriter: response}
+		infuse.Set(response, "value")
+		infuse.NextWith(response, replacement, request)
+		fmt.Fprintf(response, "flush called: %t\n", replacement.flushed)
+		fmt.Fprintln(response, "end first")
+	})
+	handler = handler.HandleFunc(buildHandler("second", 1))
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		if infuse.Get(response) != "value" {
+			t.Fatal("Expected context value to be shared with replacement response.")
+		}
+		if _, ok := response.(http.Flusher); !ok {
+			t.Fatal("Expected replacement response to be extended.")
+		}
+		response.(http.Flusher).Flush()
+		buildHandler("third", 1)(response, request)
+	})
+	testHandlerResponse(t, serve(handler), nextWithFixture)
+}
+
+var unwrapFixture = `
+start first
+attempting next for first
+start wrapped
+START SECOND
+ATTEMPTING NEXT FOR SECOND
+NO NEXT FOR SECOND
+END SECOND
+end wrapped
+finished next for first
+end first`
+
+func TestUnwrap(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.Set(response, "value")
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(buildHandler("first", 1))
+	handler = handler.Handle(upperMiddleware(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if infuse.Get(response) != "value" {
+			t.Fatal("Expected context value to be found through wrapper.")
+		}
+		fmt.Fprintln(response.(*upperResponse).ResponseWriter, "start wrapped")
+		infuse.Next(response, request)
+		fmt.Fprintln(response.(*upperResponse).ResponseWriter, "end wrapped")
+	})))
+	handler = handler.HandleFunc(buildHandler("second", 1))
+	testHandlerResponse(t, serve(handler), unwrapFixture)
+}
+
+func TestUnwrapResponse(t *testing.T) {
+	response := httptest.NewRecorder()
+	infuse.New().HandleFunc(func(sharedResponse http.ResponseWriter, _ *http.Request) {
+		unwrapper, ok := sharedResponse.(interface{ Unwrap() http.ResponseWriter })
+		if !ok || unwrapper.Unwrap() != response {
+			t.Fatal("Expected response to unwrap to the original response.")
+		}
+	}).ServeHTTP(response, &http.Request{})
+}
+
+func TestNextFromGoroutine(t *testing.T) {
+	handler := infuse.New().Recover(nil)
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			buildHandler("first", 1)(response, request)
+		}()
+		<-done
+	})
+	handler = handler.HandleFunc(buildHandler("second", 1))
+	handler = handler.HandleFunc(buildHandler("third", 1))
+	testHandlerResponse(t, serve(handler), threeHandlerFixture)
+}
+
 func TestInvalidResponseForNext(t *testing.T) {
 	if ok := infuse.Next(nil, &http.Request{}); ok {
 		t.Fatal("Expected failure to serve next handler with invalid response.")
 	}
+	if ok := infuse.NextWith(nil, httptest.NewRecorder(), &http.Request{}); ok {
+		t.Fatal("Expected failure to serve next handler with invalid response.")
+	}
 }
 
 func buildHandler(name string, nexts int) func(http.ResponseWriter, *http.Request) {
@@ -206,6 +340,29 @@ func buildHandler(name string, nexts int) func(http.ResponseWriter, *http.Reques
 	}
 }
 
+type upperResponse struct {
+	http.ResponseWriter
+	flushed bool
+}
+
+func (u *upperResponse) Write(data []byte) (int, error) {
+	return u.ResponseWriter.Write(bytes.ToUpper(data))
+}
+
+func (u *upperResponse) Unwrap() http.ResponseWriter {
+	return u.ResponseWriter
+}
+
+func (u *upperResponse) Flush() {
+	u.flushed = true
+}
+
+func upperMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		handler.ServeHTTP(&upperResponse{ResponseWriter: response}, request)
+	})
+}
+
 func panicHandler(response http.ResponseWriter, _ *http.Request) {
 	if infuse.Get(response) != nil {
 		fmt.Fprintf(response, "already panicked\n")
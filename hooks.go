@@ -0,0 +1,59 @@
+package infuse
+
+import "net/http"
+
+// BeforeWrite registers a hook that is called just before the response
+// headers are written to the response that the infuse.Handler is being
+// served with, either explicitly with WriteHeader or implicitly by writing
+// to or flushing the response. The hook is called with the response headers,
+// which it may modify, and the status code that is about to be written.
+//
+// Hooks registered by any of the http.Handlers attached to the same
+// infuse.Handler, or to an infuse.Handler nested within it, are called
+// exactly once per request, in the order that they were registered. This allows middleware to add headers (such as
+// cookies or cache headers) that depend on work done after it calls Next,
+// but must be sent before the response body.
+//
+// The boolean return value indicates whether registering the hook
+// succeeded. BeforeWrite will return false if the response headers were
+// already written or if the provided response is invalid.
+func BeforeWrite(response http.ResponseWriter, hook func(header http.Header, status int)) bool {
+	sharedResponse, ok := find(response)
+	if !ok || sharedResponse.headerWritten() {
+		return false
+	}
+	sharedResponse.addHook(hook)
+	return true
+}
+
+// addHook registers the provided hook with the outermost infuse.Handler, so
+// that the hooks registered by nested infuse.Handlers are called along with
+// the hooks registered before them, in order.
+func (s *state) addHook(hook func(http.Header, int)) {
+	if s.parent != nil {
+		s.parent.addHook(hook)
+		return
+	}
+	s.hooks = append(s.hooks, hook)
+}
+
+// swapHooks replaces the registered hooks with the provided hooks, and
+// returns the hooks that were replaced. Like addHook, it applies to the
+// outermost infuse.Handler.
+func (s *state) swapHooks(hooks []func(http.Header, int)) []func(http.Header, int) {
+	if s.parent != nil {
+		return s.parent.swapHooks(hooks)
+	}
+	prevHooks := s.hooks
+	s.hooks = hooks
+	return prevHooks
+}
+
+// runHooks calls and removes all registered hooks.
+func (c *contextualResponse) runHooks(status int) {
+	hooks := c.hooks
+	c.hooks = nil
+	for _, hook := range hooks {
+		hook(c.Header(), status)
+	}
+}
@@ -0,0 +1,91 @@
+package infuse_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+func TestBeforeWrite(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.BeforeWrite(response, func(header http.Header, status int) {
+			header.Add("X-Hooks", fmt.Sprintf("first %d", status))
+		})
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.BeforeWrite(response, func(header http.Header, status int) {
+			header.Add("X-Hooks", fmt.Sprintf("second %d", status))
+		})
+		infuse.Next(response, request)
+		if ok := infuse.BeforeWrite(response, func(http.Header, int) {
+			t.Fatal("Expected hook registered after write not to be called.")
+		}); ok {
+			t.Fatal("Expected failure to register hook after headers were written.")
+		}
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		response.Header().Set("X-Hooks", "handler")
+		response.WriteHeader(http.StatusTeapot)
+		fmt.Fprint(response, "some body")
+	})
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+
+	hooks := response.Header()["X-Hooks"]
+	if len(hooks) != 3 || hooks[0] != "handler" || hooks[1] != "first 418" || hooks[2] != "second 418" {
+		t.Fatalf("Expected hooks to be called once in order, got %v.", hooks)
+	}
+}
+
+func TestBeforeWriteOnImplicitWrite(t *testing.T) {
+	calls := 0
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.BeforeWrite(response, func(header http.Header, status int) {
+			calls++
+			header.Set("X-Status", fmt.Sprint(status))
+		})
+		fmt.Fprint(response, "first")
+		fmt.Fprint(response, "second")
+	})
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+
+	if calls != 1 || response.Header().Get("X-Status") != "200" {
+		t.Fatalf("Expected hook to be called once with status 200, got %d calls.", calls)
+	}
+}
+
+func TestBeforeWriteForNestedHandlers(t *testing.T) {
+	var order []string
+	nested := infuse.New().HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		infuse.BeforeWrite(response, func(http.Header, int) {
+			order = append(order, "inner")
+		})
+		fmt.Fprint(response, "some body")
+	})
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.BeforeWrite(response, func(http.Header, int) {
+			order = append(order, "outer")
+		})
+		infuse.Next(response, request)
+	})
+	handler = handler.Handle(nested)
+
+	handler.ServeHTTP(httptest.NewRecorder(), &http.Request{})
+
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Fatalf("Expected hooks to be called once in order, got %v.", order)
+	}
+}
+
+func TestInvalidResponseForBeforeWrite(t *testing.T) {
+	if ok := infuse.BeforeWrite(nil, func(http.Header, int) {}); ok {
+		t.Fatal("Expected failure to register hook for invalid response.")
+	}
+}
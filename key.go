@@ -0,0 +1,75 @@
+package infuse
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// A Key is a typed key for a context value that is shared by all
+// http.Handlers attached to the same infuse.Handler. Unlike Get and Value,
+// the methods of a Key never require a type assertion, so storing or
+// retrieving a value of the wrong type is a compile-time error.
+//
+// Each Key returned by NewKey is distinct, even if two keys share a name.
+// Keys are typically declared as package-level variables:
+//
+//   var userKey = infuse.NewKey[string]("user")
+//
+//   func userGreeting(response http.ResponseWriter, request *http.Request) {
+//      username := userKey.MustGet(response)
+//      fmt.Fprintf(response, "Hello %s!", username)
+//   }
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a new Key for context values of type T. The provided name
+// is only used to describe the Key.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name}
+}
+
+// Get will retrieve the context value associated with the Key. The boolean
+// return value indicates whether a value was found. Get will return the zero
+// value of T and false if the provided response is invalid or if no value is
+// associated with the Key.
+func (k *Key[T]) Get(response http.ResponseWriter) (T, bool) {
+	sharedResponse, ok := find(response)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	value, found := sharedResponse.value(k)
+	typedValue, ok := value.(T)
+	return typedValue, found && (ok || value == nil)
+}
+
+// MustGet is the same as Get, but it panics if no value is associated with
+// the Key.
+func (k *Key[T]) MustGet(response http.ResponseWriter) T {
+	value, ok := k.Get(response)
+	if !ok {
+		panic(fmt.Sprintf("infuse: no value for key %s", k))
+	}
+	return value
+}
+
+// Set will associate a context value with the Key.
+//
+// The boolean return value indicates whether setting the context value
+// succeeded. Set will return false if the provided response is invalid.
+func (k *Key[T]) Set(response http.ResponseWriter, value T) bool {
+	return SetValue(response, k, value)
+}
+
+// Delete will remove the context value associated with the Key.
+//
+// The boolean return value indicates whether deleting the context value
+// succeeded. Delete will return false if the provided response is invalid.
+func (k *Key[T]) Delete(response http.ResponseWriter) bool {
+	return DeleteValue(response, k)
+}
+
+func (k *Key[T]) String() string {
+	return k.name
+}
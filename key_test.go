@@ -0,0 +1,101 @@
+package infuse_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+var (
+	nameKey  = infuse.NewKey[string]("name")
+	countKey = infuse.NewKey[int]("count")
+)
+
+var keyFixture = `
+name: some name (true)
+count: 0 (false)
+name: some name (true)
+count: 2 (true)
+name:  (false)
+count: 2 (true)`
+
+func TestKeys(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		nameKey.Set(response, "some name")
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(outputKeysHandler)
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		countKey.Set(response, 2)
+		infuse.SetValue(response, nameKey, 3)
+		infuse.SetValue(response, nameKey, "some name")
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(outputKeysHandler)
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		nameKey.Delete(response)
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(outputKeysHandler)
+	testHandlerResponse(t, serve(handler), keyFixture)
+}
+
+func TestKeyTypeMismatch(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.SetValue(response, countKey, "not a number")
+		if count, ok := countKey.Get(response); ok || count != 0 {
+			t.Fatalf("Expected missing count for value of wrong type, got %d.", count)
+		}
+	})
+	serve(handler)
+}
+
+func TestKeyNilValue(t *testing.T) {
+	errKey := infuse.NewKey[error]("error")
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		if _, ok := errKey.Get(response); ok {
+			t.Fatal("Expected missing value before set.")
+		}
+		errKey.Set(response, nil)
+		if err, ok := errKey.Get(response); !ok || err != nil {
+			t.Fatalf("Expected nil value to be found, got %v, %t.", err, ok)
+		}
+		if err := errKey.MustGet(response); err != nil {
+			t.Fatalf("Expected nil value, got %v.", err)
+		}
+	})
+	serve(handler)
+}
+
+func TestMustGet(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "infuse: no value for key name" {
+			t.Fatalf("Expected panic for missing key, got %v.", r)
+		}
+	}()
+	serve(infuse.New().HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		nameKey.MustGet(response)
+	}))
+}
+
+func TestInvalidResponseForKeys(t *testing.T) {
+	if name, ok := nameKey.Get(nil); ok || name != "" {
+		t.Fatalf("Expected empty name from invalid response, got %s.", name)
+	}
+	if ok := nameKey.Set(nil, "value"); ok {
+		t.Fatal("Expected failure to set key on invalid response.")
+	}
+	if ok := nameKey.Delete(nil); ok {
+		t.Fatal("Expected failure to delete key on invalid response.")
+	}
+}
+
+func outputKeysHandler(response http.ResponseWriter, request *http.Request) {
+	name, ok := nameKey.Get(response)
+	fmt.Fprintf(response, "name: %s (%t)\n", name, ok)
+	count, ok := countKey.Get(response)
+	fmt.Fprintf(response, "count: %d (%t)\n", count, ok)
+	infuse.Next(response, request)
+}
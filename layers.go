@@ -0,0 +1,140 @@
+package infuse
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// A Kind describes how an http.Handler was attached to an infuse.Handler.
+type Kind int
+
+const (
+	// KindHandle is an http.Handler attached with Handle, HandleFunc, or
+	// HandleNamed.
+	KindHandle Kind = iota
+
+	// KindStack is an http.Handler attached with Stack, StackFunc, or
+	// StackNamed.
+	KindStack
+
+	// KindNested is an infuse.Handler attached to another infuse.Handler
+	// with Handle or HandleNamed.
+	KindNested
+
+	// KindHandleErr is a handler function attached with HandleErr.
+	KindHandleErr
+
+	// KindOnError is an error handler attached with OnError.
+	KindOnError
+
+	// KindRecover is a handler attached with Recover.
+	KindRecover
+
+	// KindObserve is an infuse.Observer attached with Observe.
+	KindObserve
+
+	// KindWhen is a branch attached with When.
+	KindWhen
+
+	// KindUnless is a branch attached with Unless.
+	KindUnless
+
+	// KindThen is a terminal http.Handler attached with Then or ThenFunc.
+	KindThen
+
+	// KindWrap is a standard middleware function attached with Wrap.
+	KindWrap
+
+	// KindHandleNext is a handler function attached with HandleNext.
+	KindHandleNext
+
+	// KindParallel is a set of http.Handlers attached with Parallel.
+	KindParallel
+)
+
+var kindNames = []string{"Handle", "Stack", "Nested", "HandleErr", "OnError", "Recover", "Observe", "When", "Unless", "Then", "Wrap", "HandleNext", "Parallel"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// A Layer describes an http.Handler attached to an infuse.Handler.
+type Layer struct {
+	// Name is the name provided to HandleNamed or StackNamed, or empty if
+	// the http.Handler was attached without a name.
+	Name string
+
+	// Kind describes how the http.Handler was attached.
+	Kind Kind
+
+	// Handler is the http.Handler that was attached. For branches attached
+	// with When or Unless, Handler is the branch. For handlers that are not
+	// attached as an http.Handler (such as those attached with HandleErr),
+	// Handler is the http.Handler that serves them.
+	Handler http.Handler
+
+	// Source is the file and line number of the code that attached the
+	// http.Handler, formatted as "file:line".
+	Source string
+
+	// Layers describes the layers of the attached http.Handler if it is an
+	// infuse.Handler, whether it was attached with Handle, Stack, When, or
+	// Unless. Otherwise, Layers is nil.
+	Layers []Layer
+}
+
+func (l Layer) String() string {
+	if l.Name == "" {
+		return fmt.Sprintf("%s at %s", l.Kind, l.Source)
+	}
+	return fmt.Sprintf("%s %q at %s", l.Kind, l.Name, l.Source)
+}
+
+// Layers describes the http.Handlers attached to the provided
+// infuse.Handler, in the order that they are served. Layers will return nil
+// if the provided http.Handler is not an infuse.Handler created by
+// infuse.New or if no http.Handlers are attached to it.
+func Layers(handler http.Handler) []Layer {
+	current, ok := handler.(*layer)
+	if !ok {
+		return nil
+	}
+	var layers []Layer
+	for ; current != nil; current = current.prev {
+		layers = append(layers, current.describe())
+	}
+	for i, j := 0, len(layers)-1; i < j; i, j = i+1, j-1 {
+		layers[i], layers[j] = layers[j], layers[i]
+	}
+	return layers
+}
+
+// describe returns a Layer that describes only the current layer, and not
+// the layers attached before it.
+func (l *layer) describe() Layer {
+	if l == nil {
+		return Layer{}
+	}
+	return Layer{
+		Name:    l.name,
+		Kind:    l.kind,
+		Handler: l.attached,
+		Source:  l.source,
+		Layers:  Layers(l.attached),
+	}
+}
+
+// caller returns the source location of a function call on the current
+// goroutine's stack, where skip is the number of stack frames to skip
+// (with 0 identifying the caller of caller).
+func caller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
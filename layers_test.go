@@ -0,0 +1,65 @@
+package infuse_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+func TestLayers(t *testing.T) {
+	nested := infuse.New().HandleNamed("nested first", http.HandlerFunc(buildHandler("nested first", 1)))
+	nested = nested.StackFunc(buildHandler("nested second", 0))
+
+	handler := infuse.New().Recover(nil)
+	handler = handler.HandleNamed("first", http.HandlerFunc(buildHandler("first", 1)))
+	handler = handler.StackNamed("second", http.HandlerFunc(buildHandler("second", 0)))
+	handler = handler.Handle(nested)
+	handler = handler.Stack(nested)
+
+	layers := infuse.Layers(handler)
+	expected := []struct {
+		name   string
+		kind   infuse.Kind
+		layers int
+	}{
+		{"", infuse.KindRecover, 0},
+		{"first", infuse.KindHandle, 0},
+		{"second", infuse.KindStack, 0},
+		{"", infuse.KindNested, 2},
+		{"", infuse.KindStack, 2},
+	}
+	if len(layers) != len(expected) {
+		t.Fatalf("Expected %d layers, got %d.", len(expected), len(layers))
+	}
+	for i, layer := range layers {
+		if layer.Name != expected[i].name || layer.Kind != expected[i].kind || len(layer.Layers) != expected[i].layers {
+			t.Fatalf("Unexpected layer %d: %s", i, layer)
+		}
+		if !strings.Contains(layer.Source, "layers_test.go:") {
+			t.Fatalf("Expected layer %d to be attached in layers_test.go, got %s.", i, layer.Source)
+		}
+	}
+	if layers[3].Handler != nested || layers[3].Layers[0].Name != "nested first" || layers[3].Layers[1].Kind != infuse.KindStack {
+		t.Fatalf("Unexpected nested layers: %v", layers[3].Layers)
+	}
+}
+
+func TestLayersWithoutHandlers(t *testing.T) {
+	if layers := infuse.Layers(infuse.New()); layers != nil {
+		t.Fatalf("Expected no layers for new handler, got %v.", layers)
+	}
+	if layers := infuse.Layers(http.NotFoundHandler()); layers != nil {
+		t.Fatalf("Expected no layers for plain http.Handler, got %v.", layers)
+	}
+}
+
+func TestKindString(t *testing.T) {
+	if kind := infuse.KindStack.String(); kind != "Stack" {
+		t.Fatalf("Expected Stack, got %s.", kind)
+	}
+	if kind := infuse.Kind(-1).String(); kind != "Kind(-1)" {
+		t.Fatalf("Expected Kind(-1), got %s.", kind)
+	}
+}
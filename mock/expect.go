@@ -0,0 +1,73 @@
+package mock
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+// Attachments returns a description of each handler attached to the
+// mock.Handler, in the order that a real infuse.Handler would serve them.
+func (h *Handler) Attachments() []Attachment {
+	var attachments []Attachment
+	for _, attached := range h.attachments {
+		attachments = append(attachments, attached.Attachment)
+	}
+	return attachments
+}
+
+// AttachedCount returns the number of handlers attached to the mock.Handler
+// with the provided kind.
+func (h *Handler) AttachedCount(kind infuse.Kind) int {
+	count := 0
+	for _, attached := range h.attachments {
+		if attached.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// ExpectAttached fails the test unless the provided handler was attached to
+// the mock.Handler with the provided kind. Handler functions are compared by
+// their code, so two closures created by the same function literal are
+// considered the same handler.
+func (h *Handler) ExpectAttached(t testing.TB, kind infuse.Kind, handler interface{}) {
+	t.Helper()
+	for _, attached := range h.attachments {
+		if attached.Kind == kind && same(attached.Handler, handler) {
+			return
+		}
+	}
+	t.Errorf("Expected %T to be attached with kind %s, got: %v", handler, kind, h.Attachments())
+}
+
+// ExpectOrder fails the test unless each of the provided handlers was
+// attached to the mock.Handler, in the provided order. Other handlers may be
+// attached before, after, or between them.
+func (h *Handler) ExpectOrder(t testing.TB, handlers ...interface{}) {
+	t.Helper()
+	i := 0
+	for _, attached := range h.attachments {
+		if i < len(handlers) && same(attached.Handler, handlers[i]) {
+			i++
+		}
+	}
+	if i < len(handlers) {
+		t.Errorf("Expected handler %d (%T) to be attached in order, got: %v", i, handlers[i], h.Attachments())
+	}
+}
+
+// same reports whether two attached values are the same. Functions, which
+// are not comparable, are compared by their code pointers.
+func same(a, b interface{}) bool {
+	aValue, bValue := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !aValue.IsValid() || !bValue.IsValid() {
+		return !aValue.IsValid() && !bValue.IsValid()
+	}
+	if aValue.Kind() == reflect.Func && bValue.Kind() == reflect.Func {
+		return aValue.Pointer() == bValue.Pointer()
+	}
+	return aValue.Type() == bValue.Type() && aValue.Type().Comparable() && a == b
+}
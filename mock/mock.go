@@ -3,15 +3,45 @@
 package mock
 
 import (
+	"context"
 	"net/http"
+	"runtime/debug"
 
 	"github.com/sclevine/infuse"
 )
 
-// Handler is a mock handler that will call a StubFunc when it is served.
+// Handler is a mock handler that records each handler attached to it. When
+// it is served, it calls a StubFunc, or serves the attached handlers like a
+// real infuse.Handler if no StubFunc is provided.
 type Handler struct {
-	handlers []http.Handler
-	stub     StubFunc
+	attachments []attachment
+	stub        StubFunc
+}
+
+// An Attachment describes a handler attached to a mock.Handler.
+type Attachment struct {
+	// Kind describes how the handler was attached, as it would be
+	// described by infuse.Layers.
+	Kind infuse.Kind
+
+	// Name is the name provided to HandleNamed, StackNamed, or Replace, or
+	// empty if the handler was attached without a name.
+	Name string
+
+	// Handler is the value that was provided when the handler was attached,
+	// such as an http.Handler, a handler function, an infuse.Observer, or a
+	// branch provided to When or Unless.
+	Handler interface{}
+}
+
+type attachment struct {
+	Attachment
+
+	// handler serves the attached handler, as a real infuse.Handler would.
+	handler http.Handler
+
+	// replay attaches the same handler to a real infuse.Handler.
+	replay func(infuse.Handler) infuse.Handler
 }
 
 // A StubFunc is called when a mock.Handler is served. The third argument
@@ -20,34 +50,277 @@ type Handler struct {
 type StubFunc func(http.ResponseWriter, *http.Request, []http.Handler)
 
 // Stub provides a StubFunc to a mock.Handler. If a mock.Handler is served
-// without a StubFunc, it will panic. The provided StubFunc will be inherited
-// by any derived handlers
+// without a StubFunc, it serves the attached handlers like a real
+// infuse.Handler. The provided StubFunc will be inherited by any derived
+// handlers
 func (h *Handler) Stub(stub StubFunc) {
 	h.stub = stub
 }
 
 func (h *Handler) Handle(handler http.Handler) infuse.Handler {
-	return &Handler{handlers: append(h.handlers, handler), stub: h.stub}
+	return h.HandleNamed("", handler)
 }
 
 func (h *Handler) HandleFunc(handler func(http.ResponseWriter, *http.Request)) infuse.Handler {
-	return h.Handle(http.HandlerFunc(handler))
+	return h.attach(infuse.KindHandle, "", handler, http.HandlerFunc(handler), func(chain infuse.Handler) infuse.Handler {
+		return chain.HandleFunc(handler)
+	})
+}
+
+func (h *Handler) HandleNamed(name string, handler http.Handler) infuse.Handler {
+	return h.attach(handleKind(handler), name, handler, handler, func(chain infuse.Handler) infuse.Handler {
+		return chain.HandleNamed(name, handler)
+	})
 }
 
 func (h *Handler) Stack(handler http.Handler) infuse.Handler {
-	return h.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+	return h.StackNamed("", handler)
+}
+
+func (h *Handler) StackFunc(handler func(http.ResponseWriter, *http.Request)) infuse.Handler {
+	return h.attach(infuse.KindStack, "", handler, stack(http.HandlerFunc(handler)), func(chain infuse.Handler) infuse.Handler {
+		return chain.StackFunc(handler)
+	})
+}
+
+func (h *Handler) StackNamed(name string, handler http.Handler) infuse.Handler {
+	return h.attach(infuse.KindStack, name, handler, stack(handler), func(chain infuse.Handler) infuse.Handler {
+		return chain.StackNamed(name, handler)
+	})
+}
+
+func stack(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
 		handler.ServeHTTP(response, request)
 		infuse.Next(response, request)
 	})
 }
 
-func (h *Handler) StackFunc(handler func(http.ResponseWriter, *http.Request)) infuse.Handler {
-	return h.Stack(http.HandlerFunc(handler))
+func (h *Handler) HandleErr(handler func(http.ResponseWriter, *http.Request) error) infuse.Handler {
+	errHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if err := handler(response, request); err != nil {
+			infuse.Fail(response, err)
+		}
+	})
+	return h.attach(infuse.KindHandleErr, "", handler, errHandler, func(chain infuse.Handler) infuse.Handler {
+		return chain.HandleErr(handler)
+	})
+}
+
+func (h *Handler) OnError(handler func(http.ResponseWriter, *http.Request, error)) infuse.Handler {
+	errHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if err := infuse.NextErr(response, request); err != nil && err != infuse.ErrNoNext {
+			handler(response, request, err)
+		}
+	})
+	return h.attach(infuse.KindOnError, "", handler, errHandler, func(chain infuse.Handler) infuse.Handler {
+		return chain.OnError(handler)
+	})
+}
+
+func (h *Handler) Recover(reporter func(*http.Request, *infuse.Panic)) infuse.Handler {
+	recoverHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		defer func() {
+			if value := recover(); value != nil {
+				if reporter != nil {
+					reporter(request, &infuse.Panic{Value: value, Stack: debug.Stack()})
+				}
+				status := http.StatusInternalServerError
+				http.Error(response, http.StatusText(status), status)
+			}
+		}()
+		infuse.Next(response, request)
+	})
+	return h.attach(infuse.KindRecover, "", reporter, recoverHandler, func(chain infuse.Handler) infuse.Handler {
+		return chain.Recover(reporter)
+	})
+}
+
+func (h *Handler) Observe(observer infuse.Observer) infuse.Handler {
+	return h.attach(infuse.KindObserve, "", observer, http.HandlerFunc(next), func(chain infuse.Handler) infuse.Handler {
+		return chain.Observe(observer)
+	})
+}
+
+func next(response http.ResponseWriter, request *http.Request) {
+	infuse.Next(response, request)
+}
+
+func (h *Handler) When(predicate func(*http.Request) bool, branch infuse.Handler) infuse.Handler {
+	return h.attach(infuse.KindWhen, "", branch, conditional(predicate, branch), func(chain infuse.Handler) infuse.Handler {
+		return chain.When(predicate, branch)
+	})
+}
+
+func (h *Handler) Unless(predicate func(*http.Request) bool, branch infuse.Handler) infuse.Handler {
+	negated := func(request *http.Request) bool { return !predicate(request) }
+	return h.attach(infuse.KindUnless, "", branch, conditional(negated, branch), func(chain infuse.Handler) infuse.Handler {
+		return chain.Unless(predicate, branch)
+	})
+}
+
+func conditional(predicate func(*http.Request) bool, branch infuse.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if predicate(request) {
+			infuse.Branch(response, request, branch)
+		} else {
+			infuse.Next(response, request)
+		}
+	})
+}
+
+func (h *Handler) HandleNext(handler func(http.ResponseWriter, *http.Request, http.HandlerFunc)) infuse.Handler {
+	return h.attach(infuse.KindHandleNext, "", handler, handleNext(handler), func(chain infuse.Handler) infuse.Handler {
+		return chain.HandleNext(handler)
+	})
+}
+
+func handleNext(handler func(http.ResponseWriter, *http.Request, http.HandlerFunc)) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		handler(response, request, func(next http.ResponseWriter, request *http.Request) {
+			if next == response {
+				infuse.Next(response, request)
+			} else {
+				infuse.NextWith(response, next, request)
+			}
+		})
+	})
+}
+
+func (h *Handler) Wrap(middleware func(http.Handler) http.Handler) infuse.Handler {
+	wrapped := middleware(http.HandlerFunc(wrapNext))
+	wrapHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		ctx := context.WithValue(request.Context(), wrapKey{}, response)
+		wrapped.ServeHTTP(response, request.WithContext(ctx))
+	})
+	return h.attach(infuse.KindWrap, "", middleware, wrapHandler, func(chain infuse.Handler) infuse.Handler {
+		return chain.Wrap(func(http.Handler) http.Handler { return wrapHandler })
+	})
+}
+
+type wrapKey struct{}
+
+// wrapNext is the next http.Handler provided to middleware attached with
+// Wrap. Like the next http.Handler provided by a real infuse.Handler, it
+// continues the middleware chain from the response provided to the
+// attached handler, which is carried by the request context, or from the
+// provided response if the request context does not carry it.
+func wrapNext(next http.ResponseWriter, request *http.Request) {
+	response, ok := request.Context().Value(wrapKey{}).(http.ResponseWriter)
+	if !ok || next == response {
+		infuse.Next(next, request)
+	} else {
+		infuse.NextWith(response, next, request)
+	}
+}
+
+func (h *Handler) Parallel(handlers ...http.Handler) infuse.Handler {
+	parallelHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.Parallel(response, request, handlers...)
+		infuse.Next(response, request)
+	})
+	return h.attach(infuse.KindParallel, "", handlers, parallelHandler, func(chain infuse.Handler) infuse.Handler {
+		return chain.Parallel(handlers...)
+	})
+}
+
+func (h *Handler) Prepend(handler http.Handler) infuse.Handler {
+	return h.splice(0, 0, handle("", handler))
+}
+
+func (h *Handler) InsertBefore(name string, handler http.Handler) infuse.Handler {
+	if i := h.index(name); i >= 0 {
+		return h.splice(i, 0, handle("", handler))
+	}
+	return h
+}
+
+func (h *Handler) Replace(name string, handler http.Handler) infuse.Handler {
+	if i := h.index(name); i >= 0 {
+		return h.splice(i, 1, handle(name, handler))
+	}
+	return h
+}
+
+func (h *Handler) Without(name string) infuse.Handler {
+	if i := h.index(name); i >= 0 {
+		return h.splice(i, 1)
+	}
+	return h
+}
+
+func (h *Handler) Then(handler http.Handler) http.Handler {
+	return h.attach(infuse.KindThen, "", handler, handler, func(chain infuse.Handler) infuse.Handler {
+		return chain.Then(handler).(infuse.Handler)
+	})
+}
+
+func (h *Handler) ThenFunc(handler func(http.ResponseWriter, *http.Request)) http.Handler {
+	return h.attach(infuse.KindThen, "", handler, http.HandlerFunc(handler), func(chain infuse.Handler) infuse.Handler {
+		return chain.ThenFunc(handler).(infuse.Handler)
+	})
 }
 
 func (h *Handler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
 	if h.stub == nil {
-		panic("Mock infuse.Handler missing stub.")
+		h.chain().ServeHTTP(response, request)
+		return
+	}
+	var handlers []http.Handler
+	for _, attached := range h.attachments {
+		handlers = append(handlers, attached.handler)
+	}
+	h.stub(response, request, handlers)
+}
+
+// chain returns a real infuse.Handler with the same handlers attached.
+func (h *Handler) chain() infuse.Handler {
+	chain := infuse.New()
+	for _, attached := range h.attachments {
+		chain = attached.replay(chain)
 	}
-	h.stub(response, request, h.handlers)
+	return chain
+}
+
+func (h *Handler) attach(kind infuse.Kind, name string, original interface{}, handler http.Handler, replay func(infuse.Handler) infuse.Handler) *Handler {
+	return h.splice(len(h.attachments), 0, attachment{Attachment{kind, name, original}, handler, replay})
+}
+
+// handle returns an attachment for an http.Handler attached as if by
+// HandleNamed.
+func handle(name string, handler http.Handler) attachment {
+	replay := func(chain infuse.Handler) infuse.Handler {
+		return chain.HandleNamed(name, handler)
+	}
+	return attachment{Attachment{handleKind(handler), name, handler}, handler, replay}
+}
+
+func handleKind(handler http.Handler) infuse.Kind {
+	if _, ok := handler.(infuse.Handler); ok {
+		return infuse.KindNested
+	}
+	return infuse.KindHandle
+}
+
+// index returns the index of the first attachment with the provided name,
+// or -1 if there is none. Like a real infuse.Handler, an empty name matches
+// no attachments.
+func (h *Handler) index(name string) int {
+	for i, attached := range h.attachments {
+		if name != "" && attached.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// splice returns a copy of the mock.Handler with the provided number of
+// attachments removed at the provided index, and the provided attachments
+// inserted in their place.
+func (h *Handler) splice(index, remove int, inserted ...attachment) *Handler {
+	spliced := &Handler{stub: h.stub}
+	spliced.attachments = append(spliced.attachments, h.attachments[:index]...)
+	spliced.attachments = append(spliced.attachments, inserted...)
+	spliced.attachments = append(spliced.attachments, h.attachments[index+remove:]...)
+	return spliced
 }
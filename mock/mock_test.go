@@ -64,3 +64,182 @@ func buildHandler(name string) func(http.ResponseWriter, *http.Request) {
 		fmt.Fprintf(response, "end %s\n", name)
 	}
 }
+
+type userKey struct{}
+
+var typedKey = infuse.NewKey[int]("count")
+
+func TestResponse(t *testing.T) {
+	response := mock.NewResponse()
+	response.Seed("seeded")
+	response.SeedValue(typedKey, 1)
+	response.Downstream = func(response http.ResponseWriter, request *http.Request) {
+		if user := infuse.Value(response, userKey{}); user != "bob" {
+			t.Fatalf("Expected downstream to see context value, got: %v", user)
+		}
+		response.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(response, "downstream")
+	}
+
+	middleware := func(response http.ResponseWriter, request *http.Request) {
+		if value := infuse.Get(response); value != "seeded" {
+			t.Fatalf("Expected seeded value, got: %v", value)
+		}
+		if count := typedKey.MustGet(response); count != 1 {
+			t.Fatalf("Expected seeded typed value, got: %d", count)
+		}
+		infuse.SetValue(response, userKey{}, "bob")
+		if !infuse.Next(response, request) {
+			t.Fatal("Expected next to succeed.")
+		}
+		response.Header().Set("X-After", "after")
+	}
+	request := httptest.NewRequest("GET", "/path", nil)
+	middleware(response, request)
+
+	if len(response.Nexts) != 1 || response.Nexts[0] != request {
+		t.Fatalf("Expected one call to next with the request, got: %v", response.Nexts)
+	}
+	if len(response.Sets) != 1 || response.Sets[0] != (mock.SetCall{Key: userKey{}, Value: "bob"}) {
+		t.Fatalf("Expected one recorded set, got: %v", response.Sets)
+	}
+	if response.Recorder.Code != http.StatusAccepted || response.Recorder.Body.String() != "downstream" {
+		t.Fatalf("Unexpected response: %d %q", response.Recorder.Code, response.Recorder.Body)
+	}
+	if status := infuse.Status(response); status != http.StatusAccepted {
+		t.Fatalf("Expected written status to be tracked, got: %d", status)
+	}
+}
+
+func TestResponseWithoutDownstream(t *testing.T) {
+	response := mock.NewResponse()
+	handler := func(response http.ResponseWriter, request *http.Request) {
+		if err := infuse.NextErr(response, request); err != nil {
+			t.Fatalf("Expected no error, got: %s", err)
+		}
+		fmt.Fprint(response, "done")
+	}
+	handler(response, &http.Request{})
+	handler(response, &http.Request{})
+
+	if len(response.Nexts) != 2 || response.Recorder.Body.String() != "donedone" {
+		t.Fatalf("Unexpected recording: %d nexts, body %q", len(response.Nexts), response.Recorder.Body)
+	}
+}
+
+type testObserver struct{}
+
+func (testObserver) EnterLayer(*infuse.LayerEvent) {}
+func (testObserver) ExitLayer(*infuse.LayerEvent)  {}
+
+func TestMockAttachments(t *testing.T) {
+	first := http.HandlerFunc(buildHandler("first"))
+	second := buildHandler("second")
+	nested := infuse.New().HandleFunc(buildHandler("nested"))
+	observer := testObserver{}
+
+	mockHandler := &mock.Handler{}
+	handler := mockHandler.Observe(observer)
+	handler = handler.HandleNamed("first", first)
+	handler = handler.StackFunc(second)
+	handler = handler.Handle(nested)
+	handler = handler.Prepend(http.HandlerFunc(buildHandler("prepended")))
+	handler = handler.Without("first")
+
+	recorded := handler.(*mock.Handler)
+	if count := recorded.AttachedCount(infuse.KindStack); count != 1 {
+		t.Fatalf("Expected one stacked handler, got %d.", count)
+	}
+	if count := recorded.AttachedCount(infuse.KindHandle); count != 1 {
+		t.Fatalf("Expected one handler after removal, got %d.", count)
+	}
+	recorded.ExpectAttached(t, infuse.KindStack, second)
+	recorded.ExpectAttached(t, infuse.KindNested, nested)
+	recorded.ExpectAttached(t, infuse.KindObserve, observer)
+	recorded.ExpectOrder(t, observer, second, nested)
+
+	attachments := recorded.Attachments()
+	if len(attachments) != 4 || attachments[0].Kind != infuse.KindHandle || attachments[1].Kind != infuse.KindObserve {
+		t.Fatalf("Unexpected attachments: %v", attachments)
+	}
+
+	fake := &fakeTB{TB: t}
+	recorded.ExpectAttached(fake, infuse.KindHandle, second)
+	recorded.ExpectOrder(fake, nested, second)
+	if fake.errors != 2 {
+		t.Fatalf("Expected both assertions to fail, got %d failures.", fake.errors)
+	}
+}
+
+type fakeTB struct {
+	testing.TB
+	errors int
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(string, ...interface{}) {
+	f.errors++
+}
+
+var passThroughFixture = `
+start first
+start second
+start third
+start fourth
+end fourth
+end third
+start fourth
+end fourth
+end second
+end first`
+
+func TestMockPassThrough(t *testing.T) {
+	handler := setupHandlers(&mock.Handler{})
+	testHandlerResponse(t, serve(handler), passThroughFixture)
+}
+
+func TestMockWrap(t *testing.T) {
+	constructed := 0
+	middleware := func(next http.Handler) http.Handler {
+		constructed++
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			fmt.Fprintln(response, "start middleware")
+			next.ServeHTTP(response, request)
+			fmt.Fprintln(response, "end middleware")
+		})
+	}
+
+	handler := (&mock.Handler{}).Wrap(middleware).HandleFunc(buildHandler("last"))
+	for i := 0; i < 3; i++ {
+		testHandlerResponse(t, serve(handler), "start middleware\nstart last\nend last\nend middleware")
+	}
+	if constructed != 1 {
+		t.Fatalf("Expected middleware to be called once, but it was called %d times.", constructed)
+	}
+	handler.(*mock.Handler).ExpectAttached(t, infuse.KindWrap, middleware)
+}
+
+type kindObserver struct {
+	kinds []infuse.Kind
+}
+
+func (k *kindObserver) EnterLayer(event *infuse.LayerEvent) {
+	k.kinds = append(k.kinds, event.Layer.Kind)
+}
+
+func (*kindObserver) ExitLayer(*infuse.LayerEvent) {}
+
+func TestMockPassThroughThen(t *testing.T) {
+	for _, then := range []func(infuse.Handler) http.Handler{
+		func(handler infuse.Handler) http.Handler { return handler.Then(http.HandlerFunc(buildHandler("last"))) },
+		func(handler infuse.Handler) http.Handler { return handler.ThenFunc(buildHandler("last")) },
+	} {
+		observer := &kindObserver{}
+		handler := then((&mock.Handler{}).Observe(observer))
+		testHandlerResponse(t, serve(handler), "start last\nend last")
+		if len(observer.kinds) != 1 || observer.kinds[0] != infuse.KindThen {
+			t.Fatalf("Expected the terminal handler to be served as %s, got: %v", infuse.KindThen, observer.kinds)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package mock
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/sclevine/infuse"
+)
+
+// Response is an http.ResponseWriter that can be used to unit test an
+// http.Handler that is normally attached to an infuse.Handler. Unlike an
+// *httptest.ResponseRecorder, a Response supports infuse.Next, infuse.Get,
+// infuse.Set, and the other functions in the infuse package that require a
+// response provided by an infuse.Handler.
+//
+// Everything written to a Response is recorded by its Recorder.
+type Response struct {
+	http.ResponseWriter
+
+	// Recorder records the response headers and body.
+	Recorder *httptest.ResponseRecorder
+
+	// Downstream is called each time the http.Handler under test calls
+	// infuse.Next (or infuse.NextWith), as if it were the rest of the
+	// middleware chain. If Downstream is nil, calls to infuse.Next succeed
+	// without writing anything.
+	Downstream http.HandlerFunc
+
+	// Nexts records the request passed to each call to infuse.Next.
+	Nexts []*http.Request
+
+	// Sets records each context value set with infuse.Set, infuse.SetValue,
+	// or infuse.Key.Set, in order. Values seeded with Seed or SeedValue are
+	// not recorded.
+	Sets []SetCall
+
+	seeding bool
+}
+
+// A SetCall describes a context value that was set in a Response. Values set
+// with infuse.Set are associated with an unexported key.
+type SetCall struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// NewResponse returns a new Response that records to a new
+// *httptest.ResponseRecorder.
+func NewResponse() *Response {
+	response := &Response{Recorder: httptest.NewRecorder()}
+	downstream := http.HandlerFunc(response.downstream)
+	response.ResponseWriter = infuse.NewTestResponse(response.Recorder, downstream, response.recordSet)
+	return response
+}
+
+// Seed sets the context value returned by infuse.Get without recording it.
+func (r *Response) Seed(value interface{}) {
+	r.seeding = true
+	infuse.Set(r, value)
+	r.seeding = false
+}
+
+// SeedValue associates a context value with the provided key without
+// recording it. The key may be an infuse.Key, in which case the value may be
+// retrieved with the Key's Get method.
+func (r *Response) SeedValue(key, value interface{}) {
+	r.seeding = true
+	infuse.SetValue(r, key, value)
+	r.seeding = false
+}
+
+// Unwrap returns the response returned by infuse.NewTestResponse that the
+// Response wraps.
+func (r *Response) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *Response) recordSet(key, value interface{}) {
+	if !r.seeding {
+		r.Sets = append(r.Sets, SetCall{key, value})
+	}
+}
+
+func (r *Response) downstream(response http.ResponseWriter, request *http.Request) {
+	r.Nexts = append(r.Nexts, request)
+	if r.Downstream != nil {
+		r.Downstream(response, request)
+	}
+}
@@ -0,0 +1,102 @@
+package infuse
+
+import (
+	"net/http"
+	"time"
+)
+
+// An Observer is notified each time an http.Handler attached to an
+// infuse.Handler is entered and exited (see Handler.Observe). Observers are
+// called synchronously, so they should return quickly.
+type Observer interface {
+	// EnterLayer is called just before the http.Handler described by the
+	// event is served.
+	EnterLayer(event *LayerEvent)
+
+	// ExitLayer is called with the same event after the http.Handler
+	// described by the event returns or panics.
+	ExitLayer(event *LayerEvent)
+}
+
+// A LayerEvent describes an http.Handler attached to an infuse.Handler that
+// is being served.
+type LayerEvent struct {
+	// Request is the request provided to the http.Handler.
+	Request *http.Request
+
+	// Layer describes the http.Handler.
+	Layer Layer
+
+	// Depth is the position of the http.Handler in the middleware chain,
+	// where the first http.Handler attached to the infuse.Handler is at
+	// depth 0, so that Layer is described by infuse.Layers(handler)[Depth].
+	// If the http.Handler was attached to a branch (see Handler.When), Depth
+	// is its position in the branch.
+	Depth int
+
+	// Start is the time that the http.Handler was entered.
+	Start time.Time
+
+	// Duration is the time it took to serve the http.Handler, including the
+	// time taken by any subsequent http.Handlers that it called with
+	// infuse.Next. It is only set when the http.Handler is exited.
+	Duration time.Duration
+
+	// CalledNext reports whether the http.Handler called infuse.Next (or an
+	// equivalent function). It is only set when the http.Handler is exited.
+	// An http.Handler that did not call infuse.Next ended the chain.
+	CalledNext bool
+
+	// Panicked reports whether the http.Handler was exited because of a
+	// panic.
+	Panicked bool
+
+	// Status is the status code written to the response when the event
+	// was created or exited, or 0 if the response headers were not written
+	// yet (see infuse.Status).
+	Status int
+}
+
+func (l *layer) Observe(observer Observer) Handler {
+	observeHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		sharedResponse, ok := find(response)
+		if !ok {
+			return
+		}
+		sharedResponse.addObserver(observer)
+		defer sharedResponse.removeObserver()
+		Next(response, request)
+	})
+	return l.attach("", KindObserve, observeHandler, observeHandler)
+}
+
+func (s *state) addObserver(observer Observer) {
+	s.observers = append(s.observers, observer)
+}
+
+func (s *state) removeObserver() {
+	s.observers = s.observers[:len(s.observers)-1]
+}
+
+func (l *layeredResponse) enter(layer *layer, request *http.Request) *LayerEvent {
+	event := &LayerEvent{
+		Request: request,
+		Layer:   layer.describe(),
+		Depth:   l.depth,
+		Start:   time.Now(),
+		Status:  l.status,
+	}
+	for _, observer := range l.observers {
+		observer.EnterLayer(event)
+	}
+	return event
+}
+
+func (l *layeredResponse) exit(event *LayerEvent, panicked bool) {
+	event.Duration = time.Since(event.Start)
+	event.Panicked = panicked
+	event.Status = l.status
+	for i := len(l.observers) - 1; i >= 0; i-- {
+		l.observers[i].ExitLayer(event)
+	}
+}
@@ -0,0 +1,75 @@
+package infuse_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+type recordingObserver struct {
+	name   string
+	events *[]string
+}
+
+func (r recordingObserver) EnterLayer(event *infuse.LayerEvent) {
+	*r.events = append(*r.events, fmt.Sprintf("%s enter %s at %d: %d", r.name, event.Layer, event.Depth, event.Status))
+}
+
+func (r recordingObserver) ExitLayer(event *infuse.LayerEvent) {
+	if event.Duration < 0 {
+		panic("Expected non-negative duration.")
+	}
+	*r.events = append(*r.events, fmt.Sprintf("%s exit %s at %d: %d %t %t", r.name, event.Layer, event.Depth, event.Status, event.CalledNext, event.Panicked))
+}
+
+var observerFixture = `
+outer enter Handle "first" at 1: 0
+outer enter Observe at 2: 0
+outer enter Handle "second" at 3: 0
+inner enter Handle "second" at 3: 0
+outer enter Handle "short-circuit" at 4: 0
+inner enter Handle "short-circuit" at 4: 0
+inner exit Handle "short-circuit" at 4: 403 false false
+outer exit Handle "short-circuit" at 4: 403 false false
+inner exit Handle "second" at 3: 403 true false
+outer exit Handle "second" at 3: 403 true false
+outer exit Observe at 2: 403 true false
+outer exit Handle "first" at 1: 403 true false`
+
+func TestObserve(t *testing.T) {
+	var events []string
+	handler := infuse.New().Observe(recordingObserver{"outer", &events})
+	handler = handler.HandleNamed("first", http.HandlerFunc(passHandler))
+	handler = handler.Observe(recordingObserver{"inner", &events})
+	handler = handler.HandleNamed("second", http.HandlerFunc(passHandler))
+	handler = handler.HandleNamed("short-circuit", http.HandlerFunc(func(response http.ResponseWriter, _ *http.Request) {
+		response.WriteHeader(http.StatusForbidden)
+	}))
+	handler = handler.HandleNamed("unreachable", http.HandlerFunc(passHandler))
+	serve(handler)
+
+	output := ""
+	for _, event := range events {
+		output += event[:strings.Index(event, " at /")] + event[strings.LastIndex(event, " at "):] + "\n"
+	}
+	testHandlerResponse(t, output, observerFixture)
+}
+
+func TestObservePanic(t *testing.T) {
+	var events []string
+	handler := infuse.New().Recover(func(*http.Request, *infuse.Panic) {})
+	handler = handler.Observe(recordingObserver{"observer", &events})
+	handler = handler.HandleNamed("panic", http.HandlerFunc(namedPanicHandler))
+	serve(handler)
+
+	if len(events) != 2 || !strings.HasSuffix(events[1], " at 2: 0 false true") {
+		t.Fatalf("Expected exit after panic, got %v.", events)
+	}
+}
+
+func passHandler(response http.ResponseWriter, request *http.Request) {
+	infuse.Next(response, request)
+}
@@ -0,0 +1,81 @@
+package infuse
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sync"
+)
+
+type recordingsKey struct{}
+
+// Parallel serves each of the provided http.Handlers concurrently, each
+// with its own Recording, and waits for all of them to return. The
+// Recordings are returned in the same order as the provided http.Handlers,
+// and they are stored as a context value that may be retrieved with
+// Recordings, so that an http.Handler attached after the current
+// http.Handler can merge them into the response.
+//
+// The provided http.Handlers are served with a request that has a context
+// returned by WithContext, so that they can read the context values shared
+// by the http.Handlers attached to the same infuse.Handler. They must not
+// call Next or modify the context values. If any of the provided
+// http.Handlers panic, Parallel panics with the first value recovered after
+// all of them return. The value is wrapped, so that a handler attached with
+// Handler.Recover reports the original value and the stack trace of the
+// goroutine that panicked.
+//
+// Parallel will return nil if the response is invalid.
+func Parallel(response http.ResponseWriter, request *http.Request, handlers ...http.Handler) []*Recording {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return nil
+	}
+	request = WithContext(response, request)
+	recordings := make([]*Recording, len(handlers))
+	panics := make([]*stackPanic, len(handlers))
+
+	var wg sync.WaitGroup
+	for i, handler := range handlers {
+		recordings[i] = &Recording{}
+		wg.Add(1)
+		go func(i int, handler http.Handler) {
+			defer wg.Done()
+			defer func() {
+				if value := recover(); value != nil {
+					panics[i] = &stackPanic{value, debug.Stack()}
+				}
+			}()
+			handler.ServeHTTP(recordings[i], request)
+		}(i, handler)
+	}
+	wg.Wait()
+
+	for _, p := range panics {
+		if p == nil {
+			continue
+		}
+		if p.value == http.ErrAbortHandler {
+			panic(p.value)
+		}
+		panic(p)
+	}
+	sharedResponse.setValue(recordingsKey{}, recordings)
+	return recordings
+}
+
+// Recordings returns the Recordings stored by the most recent call to
+// Parallel (or the most recently served http.Handler attached with
+// Handler.Parallel) with the provided response. Recordings will return nil
+// if Parallel has not been called or if the response is invalid.
+func Recordings(response http.ResponseWriter) []*Recording {
+	recordings, _ := Value(response, recordingsKey{}).([]*Recording)
+	return recordings
+}
+
+func (l *layer) Parallel(handlers ...http.Handler) Handler {
+	parallelHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		Parallel(response, request, handlers...)
+		Next(response, request)
+	})
+	return l.attach("", KindParallel, parallelHandler, parallelHandler)
+}
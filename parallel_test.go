@@ -0,0 +1,101 @@
+package infuse_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sclevine/infuse"
+)
+
+func buildBackend(name string, status int, started *sync.WaitGroup) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		started.Done()
+		done := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			panic("backends were not served concurrently")
+		}
+		response.Header().Set("X-Backend", name)
+		response.WriteHeader(status)
+		fmt.Fprintf(response, "%s for %v", name, request.Context().Value(firstKey{}))
+	})
+}
+
+func TestParallel(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(2)
+
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.SetValue(response, firstKey{}, "user")
+		infuse.Next(response, request)
+	})
+	handler = handler.Parallel(
+		buildBackend("first", http.StatusOK, &started),
+		buildBackend("second", http.StatusAccepted, &started),
+	)
+	handler = handler.HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		for _, recording := range infuse.Recordings(response) {
+			fmt.Fprintf(response, "%d %s: %s\n", recording.Status, recording.Header().Get("X-Backend"), recording.Body.String())
+		}
+	})
+
+	testHandlerResponse(t, serve(handler), "200 first: first for user\n202 second: second for user")
+}
+
+func TestParallelPanic(t *testing.T) {
+	var recovered *infuse.Panic
+	handler := infuse.New().Recover(func(_ *http.Request, p *infuse.Panic) {
+		recovered = p
+	})
+	handler = handler.Parallel(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+		http.HandlerFunc(failingBackend),
+	)
+	handler = handler.HandleFunc(buildHandler("never", 0))
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+
+	if recovered == nil || recovered.Value != "backend failed" || recovered.Layer.Kind != infuse.KindParallel {
+		t.Fatalf("Expected panic to be recovered from parallel layer, got: %v", recovered)
+	}
+	if !strings.Contains(string(recovered.Stack), "failingBackend") {
+		t.Fatalf("Expected stack to contain failing backend, got:\n%s", recovered.Stack)
+	}
+	if response.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500, got %d.", response.Code)
+	}
+}
+
+func failingBackend(http.ResponseWriter, *http.Request) {
+	panic("backend failed")
+}
+
+func TestInvalidResponseForParallel(t *testing.T) {
+	if recordings := infuse.Parallel(nil, &http.Request{}, http.NotFoundHandler()); recordings != nil {
+		t.Fatal("Expected failure to serve handlers with invalid response.")
+	}
+	if recordings := infuse.Recordings(nil); recordings != nil {
+		t.Fatal("Expected no recordings for invalid response.")
+	}
+}
+
+func TestRecording(t *testing.T) {
+	recording := &infuse.Recording{}
+	recording.WriteHeader(http.StatusContinue)
+	fmt.Fprint(recording, "body")
+	recording.WriteHeader(http.StatusNotFound)
+	if recording.Status != http.StatusOK || recording.Body.String() != "body" {
+		t.Fatalf("Unexpected recording: %d %q", recording.Status, recording.Body.String())
+	}
+}
@@ -0,0 +1,55 @@
+package infuse
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// A Recording is an http.ResponseWriter that buffers the status code,
+// headers, and body written to it. The zero value is ready to use.
+type Recording struct {
+	// Status is the status code written to the Recording. Status is
+	// http.StatusOK if the body was written without writing a status code,
+	// and zero if nothing was written.
+	Status int
+
+	// Body is the body written to the Recording.
+	Body bytes.Buffer
+
+	// Err is the error that propagated up from the http.Handlers served by
+	// NextCapture, or nil if they succeeded.
+	Err error
+
+	header http.Header
+	hooks  []func(http.Header, int)
+}
+
+// Header returns the headers of the Recording.
+func (r *Recording) Header() http.Header {
+	if r.header == nil {
+		r.header = http.Header{}
+	}
+	return r.header
+}
+
+// WriteHeader records the provided status code, unless a status code was
+// already recorded. Informational status codes other than
+// http.StatusSwitchingProtocols are not recorded.
+func (r *Recording) WriteHeader(status int) {
+	if r.Status != 0 || (status >= 100 && status < 200 && status != http.StatusSwitchingProtocols) {
+		return
+	}
+	r.Status = status
+}
+
+// Write appends the provided data to the body of the Recording.
+func (r *Recording) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.Body.Write(data)
+}
+
+// WriteString appends the provided string to the body of the Recording.
+func (r *Recording) WriteString(data string) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.Body.WriteString(data)
+}
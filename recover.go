@@ -0,0 +1,104 @@
+package infuse
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// A Panic describes a panic recovered by a handler attached with
+// Handler.Recover.
+type Panic struct {
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Layer describes the attached http.Handler that panicked. If the panic
+	// occurred in an infuse.Handler nested in the recovering
+	// infuse.Handler, Layer describes the nested infuse.Handler.
+	Layer Layer
+
+	// Index is the position of Layer in the middleware chain, where the
+	// first http.Handler attached to the infuse.Handler is at index 0, so
+	// that Layer is described by infuse.Layers(handler)[Index]. If Layer was
+	// attached to a branch (see Handler.When), Index is its position in the
+	// branch.
+	Index int
+
+	// Stack is a formatted stack trace of the goroutine that panicked, as
+	// returned by runtime/debug.Stack.
+	Stack []byte
+}
+
+func (p *Panic) Error() string {
+	return fmt.Sprintf("infuse: panic in handler %d (%s): %v", p.Index, p.Layer, p.Value)
+}
+
+func (l *layer) Recover(reporter func(*http.Request, *Panic)) Handler {
+	if reporter == nil {
+		reporter = logPanic
+	}
+	recoverHandler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+			if value == http.ErrAbortHandler {
+				panic(value)
+			}
+			p := &Panic{Value: value, Stack: debug.Stack()}
+			if recovered, ok := value.(*stackPanic); ok {
+				p.Value, p.Stack = recovered.value, recovered.stack
+			}
+			sharedResponse, ok := find(response)
+			if ok {
+				var panicked *layer
+				panicked, p.Index = sharedResponse.panicked()
+				p.Layer = panicked.describe()
+			}
+			reporter(request, p)
+			if !ok || !sharedResponse.headerWritten() {
+				status := http.StatusInternalServerError
+				http.Error(response, http.StatusText(status), status)
+			}
+		}()
+		Next(response, request)
+	})
+	return l.attach("", KindRecover, recoverHandler, recoverHandler)
+}
+
+func logPanic(request *http.Request, p *Panic) {
+	log.Printf("%s serving %s\n%s", p, request.URL, p.Stack)
+}
+
+// A stackPanic is a panic value that was recovered on another goroutine
+// (see Parallel), with the stack trace of that goroutine.
+type stackPanic struct {
+	value interface{}
+	stack []byte
+}
+
+func (s *stackPanic) String() string {
+	return fmt.Sprintf("%v\n%s", s.value, s.stack)
+}
+
+// recordPanic records the provided layer as the source of a panic, unless a
+// layer that it called already panicked.
+func (s *state) recordPanic(layer *layer, index int) {
+	if s.panicLayer == nil {
+		s.panicLayer = layer
+		s.panicIndex = index
+	}
+}
+
+// clearPanic clears the record of a recovered panic, if there is one.
+func (s *state) clearPanic() {
+	if s.panicLayer != nil {
+		s.panicLayer = nil
+	}
+}
+
+func (s *state) panicked() (layer *layer, index int) {
+	return s.panicLayer, s.panicIndex
+}
@@ -0,0 +1,148 @@
+package infuse_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+func TestRecover(t *testing.T) {
+	var reported *infuse.Panic
+	handler := infuse.New().Recover(func(_ *http.Request, p *infuse.Panic) {
+		reported = p
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(namedPanicHandler)
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+
+	if reported == nil || reported.Value != "some error" {
+		t.Fatalf("Expected panic to be reported, got %v.", reported)
+	}
+	if reported.Index != 2 {
+		t.Fatalf("Expected panic in handler 2, got %d.", reported.Index)
+	}
+	if !strings.Contains(string(reported.Stack), "namedPanicHandler") {
+		t.Fatalf("Expected stack to contain panicking handler, got:\n%s", reported.Stack)
+	}
+	if response.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d.", response.Code)
+	}
+}
+
+func TestRecoverAfterHeaderWritten(t *testing.T) {
+	handler := infuse.New().Recover(func(*http.Request, *infuse.Panic) {})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(http.StatusAccepted)
+		fmt.Fprintln(response, "some body")
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(namedPanicHandler)
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, &http.Request{})
+
+	if response.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d.", response.Code)
+	}
+	testHandlerResponse(t, response.Body.String(), "some body")
+}
+
+func TestRecoverAttribution(t *testing.T) {
+	var reported *infuse.Panic
+	nested := infuse.New().HandleFunc(buildHandler("nested", 1)).HandleFunc(namedPanicHandler)
+
+	handler := infuse.New().Recover(func(_ *http.Request, p *infuse.Panic) {
+		reported = p
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		defer func() {
+			recover()
+			infuse.Next(response, request)
+		}()
+		infuse.Next(response, request)
+	})
+	handler = handler.HandleFunc(buildHandler("first", 1))
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		if infuse.Get(response) == nil {
+			infuse.Set(response, true)
+			panic("first error")
+		}
+		infuse.Next(response, request)
+	})
+	handler = handler.Handle(nested)
+	serve(handler)
+
+	if reported == nil || reported.Layer.Handler != nested || reported.Index != 4 {
+		t.Fatalf("Expected panic in nested handler 4, got %v.", reported)
+	}
+}
+
+func TestRecoverIndexAfterBranch(t *testing.T) {
+	var reported *infuse.Panic
+	pass := func(response http.ResponseWriter, request *http.Request) {
+		infuse.Next(response, request)
+	}
+	always := func(*http.Request) bool { return true }
+
+	handler := infuse.New().Recover(func(_ *http.Request, p *infuse.Panic) {
+		reported = p
+	})
+	handler = handler.When(always, infuse.New().HandleFunc(pass))
+	handler = handler.HandleFunc(namedPanicHandler)
+	handler.ServeHTTP(httptest.NewRecorder(), &http.Request{})
+
+	layers := infuse.Layers(handler)
+	if reported == nil || reported.Index != 2 || reported.Layer.Source != layers[reported.Index].Source {
+		t.Fatalf("Expected panic in handler 2 to match layers, got %v.", reported)
+	}
+
+	branch := infuse.New().HandleFunc(pass).HandleFunc(namedPanicHandler)
+	handler = infuse.New().Recover(func(_ *http.Request, p *infuse.Panic) {
+		reported = p
+	})
+	handler = handler.When(always, branch)
+	handler.ServeHTTP(httptest.NewRecorder(), &http.Request{})
+
+	if reported.Index != 1 || reported.Layer.Source != infuse.Layers(branch)[1].Source {
+		t.Fatalf("Expected panic in branch handler 1, got %v.", reported)
+	}
+}
+
+func TestRecoverWithoutReporter(t *testing.T) {
+	output := &bytes.Buffer{}
+	log.SetOutput(output)
+	defer log.SetOutput(os.Stderr)
+
+	serve(infuse.New().Recover(nil).HandleFunc(namedPanicHandler))
+	if !strings.Contains(output.String(), "infuse: panic in handler 1 (Handle at ") ||
+		!strings.Contains(output.String(), "recover_test.go:") ||
+		!strings.Contains(output.String(), "): some error") {
+		t.Fatalf("Expected panic to be logged, got:\n%s", output)
+	}
+}
+
+func TestRecoverAbortHandler(t *testing.T) {
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Fatalf("Expected http.ErrAbortHandler to be re-panicked, got %v.", r)
+		}
+	}()
+	serve(infuse.New().Recover(nil).HandleFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+}
+
+func namedPanicHandler(http.ResponseWriter, *http.Request) {
+	panic("some error")
+}
@@ -1,56 +1,202 @@
 package infuse
 
-import (
-	"io"
-	"net/http"
-)
+import "net/http"
 
 type infuseResponse interface {
 	next(request *http.Request) bool
-	get() interface{}
-	set(value interface{})
+	nextWith(response http.ResponseWriter, request *http.Request) bool
+	branch(branch *layer, request *http.Request) bool
+	skipTo(name string, request *http.Request) bool
+	last(request *http.Request) bool
+	abort()
+	isAborted() bool
+	value(key interface{}) (interface{}, bool)
+	setValue(key, value interface{})
+	setScope(scope scope)
+	deleteValue(key interface{})
+	failure() error
+	fail(err error)
+	headerWritten() bool
+	writtenStatus() int
+	bytesWritten() int64
+	addHook(hook func(http.Header, int))
+	swapHooks(hooks []func(http.Header, int)) []func(http.Header, int)
+	addObserver(observer Observer)
+	removeObserver()
+	panicked() (layer *layer, index int)
 }
 
-type httpResponse interface {
-	http.CloseNotifier
-	http.Flusher
-	http.Hijacker
-	io.ReaderFrom
-	stringWriter
+type unwrapper interface {
+	Unwrap() http.ResponseWriter
 }
 
+// find returns the infuseResponse provided by an infuse.Handler, following
+// any Unwrap methods of wrappers around it.
+func find(response http.ResponseWriter) (infuseResponse, bool) {
+	for response != nil {
+		if sharedResponse, ok := response.(infuseResponse); ok {
+			return sharedResponse, true
+		}
+		wrapper, ok := response.(unwrapper)
+		if !ok {
+			break
+		}
+		response = wrapper.Unwrap()
+	}
+	return nil, false
+}
+
+// A layeredResponse is provided to each layer of an infuse.Handler. To avoid
+// allocating a response for each layer, the layers served with the same
+// *contextualResponse share a *layeredResponse, and its position in the
+// middleware chain is updated while each layer is served and restored
+// afterwards.
 type layeredResponse struct {
 	*contextualResponse
+	position
+	extended http.ResponseWriter
+}
+
+// A position describes the layers that remain to be served after the layer
+// that is currently served.
+type position struct {
+	layers []*layer
+	rest   *continuation
+	depth  int
+	event  *LayerEvent
+}
+
+// A continuation holds the layers that remain to be served after the layers
+// of a branch (see Branch), and the depth of the layer that served the
+// branch. The layers of a branch are numbered from zero, so that the depth of
+// each layer is its index in the infuse.Handler that it was attached to.
+type continuation struct {
 	layers []*layer
+	rest   *continuation
+	depth  int
 }
 
+// newLayeredResponse returns a *layeredResponse with new state. If the
+// provided response was provided by another infuse.Handler, it is recorded
+// as the parent of the new state.
 func newLayeredResponse(response http.ResponseWriter) *layeredResponse {
-	return &layeredResponse{&contextualResponse{response, nil}, nil}
+	parent, _ := find(response)
+	return &layeredResponse{contextualResponse: &contextualResponse{response, &state{parent: parent}, true}}
+}
+
+// Unwrap returns the http.ResponseWriter wrapped by the *layeredResponse,
+// so that an http.ResponseController can find methods that are not
+// provided by extend.
+func (l *layeredResponse) Unwrap() http.ResponseWriter {
+	return l.ResponseWriter
 }
 
 func (l *layeredResponse) next(request *http.Request) bool {
-	if len(l.layers) == 0 {
+	return l.serveNext(l.contextualResponse, request, nil, false)
+}
+
+func (l *layeredResponse) nextWith(response http.ResponseWriter, request *http.Request) bool {
+	return l.serveNext(&contextualResponse{response, l.state, false}, request, nil, false)
+}
+
+// serveNext serves the first remaining layer for which match returns true,
+// skipping the layers before it. If last is true, the final remaining layer
+// for which match returns true is served instead. A nil match matches any
+// layer.
+func (l *layeredResponse) serveNext(response *contextualResponse, request *http.Request, match func(*layer) bool, last bool) bool {
+	if l.event != nil {
+		l.event.CalledNext = true
+	}
+	if l.aborted {
 		return false
 	}
 
-	next := l.layers[len(l.layers)-1]
-	remaining := l.layers[:len(l.layers)-1]
-	sharedResponse := &layeredResponse{l.contextualResponse, remaining}
-	next.handler.ServeHTTP(sharedResponse.extend(), request)
+	var next *layer
+	var remaining position
+	layers, rest, depth := l.layers, l.rest, l.depth
+	for {
+		for i := len(layers) - 1; i >= 0; i-- {
+			depth++
+			if match == nil || match(layers[i]) {
+				next = layers[i]
+				remaining = position{layers[:i], rest, depth, nil}
+				if !last {
+					break
+				}
+			}
+		}
+		if (next != nil && !last) || rest == nil {
+			break
+		}
+		layers, rest, depth = rest.layers, rest.rest, rest.depth
+	}
+	if next == nil {
+		return false
+	}
+	if response != l.contextualResponse {
+		sharedResponse := &layeredResponse{contextualResponse: response, position: remaining}
+		sharedResponse.serve(next, request)
+		return true
+	}
+	defer l.restore(l.position)
+	l.position = remaining
+	l.serve(next, request)
 	return true
 }
 
-// extend detects if the underlying response is a *http.response or
-// *httptest.ResponseRecorder and returns the *layeredResponse extended with
-// any extra methods defined on those types. This allows a http.ResponseWriter
-// provided to handlers to be type-asserted into an http.Flusher,
-// http.CloseNotifier, http.Hijacker, etc.
-func (l *layeredResponse) extend() http.ResponseWriter {
-	if _, ok := l.ResponseWriter.(httpResponse); ok {
-		return &fullResponse{l}
+func (l *layeredResponse) branch(branch *layer, request *http.Request) bool {
+	if l.event != nil {
+		l.event.CalledNext = true
+	}
+	if l.aborted {
+		return false
 	}
-	if _, ok := l.ResponseWriter.(http.Flusher); ok {
-		return &flushableResponse{l}
+	defer l.restore(l.position)
+	l.position = position{branch.flatten(), &continuation{l.layers, l.rest, l.depth}, -1, nil}
+	return l.next(request)
+}
+
+func (l *layeredResponse) restore(saved position) {
+	l.position = saved
+}
+
+// serve serves the provided layer with the extended *layeredResponse. If the
+// layer panics, it is recorded as the source of the panic. As serving a layer
+// or returning from one means that any earlier panic was recovered, the
+// record of any earlier panic is cleared. The record is only written when a
+// panic occurs or was recovered, so that serving a layer does not otherwise
+// write to the state shared by the layers. Any installed observers are
+// notified when the layer is entered and exited.
+func (l *layeredResponse) serve(layer *layer, request *http.Request) {
+	l.clearPanic()
+	if len(l.observers) > 0 {
+		l.event = l.enter(layer, request)
+	}
+	completed := false
+	defer func() {
+		if !completed {
+			l.recordPanic(layer, l.depth)
+		}
+		if l.event != nil {
+			l.exit(l.event, !completed)
+		}
+	}()
+	layer.handler.ServeHTTP(l.extend(), request)
+	completed = true
+	l.clearPanic()
+}
+
+// extend returns the *layeredResponse extended with the optional methods
+// defined on the underlying response, such as those defined on
+// *http.response or *httptest.ResponseRecorder. Any combination of
+// http.CloseNotifier, http.Flusher, http.Hijacker, http.Pusher,
+// io.ReaderFrom, and io.StringWriter is preserved. This allows a
+// http.ResponseWriter provided to handlers to be type-asserted into the same
+// interfaces as the underlying response. The extended response is created
+// once for each *layeredResponse.
+func (l *layeredResponse) extend() http.ResponseWriter {
+	if l.extended == nil {
+		l.extended = extendResponse(l, extensions(l.ResponseWriter))
 	}
-	return l
+	return l.extended
 }
@@ -0,0 +1,216 @@
+// Package router dispatches requests to infuse.Handlers by method and path
+// pattern. Path parameters matched by a pattern are stored in the context
+// values shared by the http.Handlers attached to the matching
+// infuse.Handler, so that they can be retrieved with Param.
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/sclevine/infuse"
+)
+
+var paramsKey = infuse.NewKey[map[string]string]("router params")
+
+// Param returns the value of the path parameter with the provided name that
+// was matched by the route being served. Param will return an empty string
+// if no such parameter was matched or if the provided response was not
+// provided by a Router.
+func Param(response http.ResponseWriter, name string) string {
+	params, _ := paramsKey.Get(response)
+	return params[name]
+}
+
+// Params returns all path parameters matched by the route being served,
+// indexed by name. Params will return nil if the provided response was not
+// provided by a Router.
+func Params(response http.ResponseWriter) map[string]string {
+	params, _ := paramsKey.Get(response)
+	return params
+}
+
+// A Router is an http.Handler that serves the http.Handler registered for
+// the method and path of each request.
+//
+// Patterns consist of segments separated by slashes. Each segment is either
+// a literal, a parameter of the form {name} that matches any non-empty
+// segment, or (as the last segment only) a wildcard of the form {name...}
+// that matches the rest of the path. Literal segments take precedence over
+// parameters, which take precedence over wildcards. For example:
+//
+//   r := router.New()
+//   r.HandleFunc("GET", "/users/{id}", showUser)
+//   r.HandleFunc("GET", "/static/{path...}", serveStatic)
+//
+// Paths are split into segments before they are unescaped, so that an
+// escaped slash (%2F) is matched as part of a segment. Parameter values are
+// unescaped before they are stored.
+//
+// A route registered for GET also matches HEAD requests, unless a route is
+// registered for HEAD explicitly.
+type Router struct {
+	// NotFound is served when no route matches the path of a request. If
+	// NotFound is nil, http.NotFound is used.
+	NotFound http.Handler
+
+	// MethodNotAllowed is served when a route matches the path of a
+	// request, but not its method. The Allow header is set before
+	// MethodNotAllowed is served. If MethodNotAllowed is nil, a 405 Method
+	// Not Allowed response is written.
+	MethodNotAllowed http.Handler
+
+	root    *node
+	handler infuse.Handler
+}
+
+// New returns a new Router with no routes.
+func New() *Router {
+	r := &Router{root: &node{}}
+	r.handler = infuse.New().HandleFunc(r.dispatch)
+	return r
+}
+
+// Handle registers the provided http.Handler for requests with the provided
+// method and a path that matches the provided pattern. If the http.Handler
+// is an infuse.Handler, the matched path parameters can be retrieved by
+// every http.Handler attached to it. Handle panics if the pattern is invalid
+// or if a route is already registered for the same method and pattern.
+func (r *Router) Handle(method, pattern string, handler http.Handler) {
+	r.root.insert(method, pattern, join(nil, handler))
+}
+
+// HandleFunc is the same as Handle, but it takes a handler function instead
+// of an http.Handler.
+func (r *Router) HandleFunc(method, pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.Handle(method, pattern, http.HandlerFunc(handler))
+}
+
+// Group returns a Group of routes that share the provided path prefix and
+// base infuse.Handler. The base may be nil.
+func (r *Router) Group(prefix string, base infuse.Handler) *Group {
+	return &Group{r, prefix, base}
+}
+
+func (r *Router) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	r.handler.ServeHTTP(response, request)
+}
+
+func (r *Router) dispatch(response http.ResponseWriter, request *http.Request) {
+	n, values := r.root.match(segments(request.URL.EscapedPath()), nil)
+	if n == nil {
+		r.notFound(response, request)
+		return
+	}
+	handler, ok := n.routes[request.Method]
+	if !ok && request.Method == http.MethodHead {
+		handler, ok = n.routes[http.MethodGet]
+	}
+	if !ok {
+		r.methodNotAllowed(response, request, n)
+		return
+	}
+	if len(values) > 0 {
+		params := make(map[string]string, len(values))
+		for i, name := range n.params {
+			params[name] = unescape(values[i])
+		}
+		paramsKey.Set(response, params)
+	}
+	infuse.Branch(response, request, handler)
+}
+
+func (r *Router) notFound(response http.ResponseWriter, request *http.Request) {
+	if r.NotFound != nil {
+		r.NotFound.ServeHTTP(response, request)
+		return
+	}
+	http.NotFound(response, request)
+}
+
+func (r *Router) methodNotAllowed(response http.ResponseWriter, request *http.Request, n *node) {
+	methods := make([]string, 0, len(n.routes))
+	for method := range n.routes {
+		methods = append(methods, method)
+	}
+	if _, ok := n.routes[http.MethodGet]; ok {
+		if _, ok := n.routes[http.MethodHead]; !ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	sort.Strings(methods)
+	response.Header().Set("Allow", strings.Join(methods, ", "))
+	if r.MethodNotAllowed != nil {
+		r.MethodNotAllowed.ServeHTTP(response, request)
+		return
+	}
+	status := http.StatusMethodNotAllowed
+	http.Error(response, http.StatusText(status), status)
+}
+
+// A Group registers routes on a Router that share a path prefix and a base
+// infuse.Handler. The base is served before the http.Handler registered for
+// each route, with the same context values, so that it can share
+// middleware (such as authentication) between routes. Like a branched
+// infuse.Handler, the base is not modified by registering routes.
+type Group struct {
+	router *Router
+	prefix string
+	base   infuse.Handler
+}
+
+// Handle is the same as Router.Handle, but the pattern is prefixed with the
+// prefix of the Group, and the base of the Group is served first.
+func (g *Group) Handle(method, pattern string, handler http.Handler) {
+	g.router.root.insert(method, g.prefix+pattern, join(g.base, handler))
+}
+
+// HandleFunc is the same as Handle, but it takes a handler function instead
+// of an http.Handler.
+func (g *Group) HandleFunc(method, pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	g.Handle(method, pattern, http.HandlerFunc(handler))
+}
+
+// Group returns a nested Group whose prefix is appended to the prefix of
+// the current Group and whose base is served after the base of the current
+// Group. The base may be nil.
+func (g *Group) Group(prefix string, base infuse.Handler) *Group {
+	if base == nil {
+		return &Group{g.router, g.prefix + prefix, g.base}
+	}
+	return &Group{g.router, g.prefix + prefix, join(g.base, base)}
+}
+
+// join returns an infuse.Handler that serves the base followed by the
+// provided handler. If the handler is an infuse.Handler, its http.Handlers
+// share the context values of the base.
+func join(base infuse.Handler, handler http.Handler) infuse.Handler {
+	if base == nil {
+		base = infuse.New()
+	}
+	chain, ok := handler.(infuse.Handler)
+	if !ok {
+		return base.Handle(handler)
+	}
+	return base.When(always, chain)
+}
+
+func always(*http.Request) bool {
+	return true
+}
+
+func segments(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+// unescape returns the provided escaped path segment (or segments, for a
+// wildcard) without escaping. If the segment is not validly escaped, it is
+// returned unchanged.
+func unescape(segment string) string {
+	if unescaped, err := url.PathUnescape(segment); err == nil {
+		return unescaped
+	}
+	return segment
+}
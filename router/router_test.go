@@ -0,0 +1,149 @@
+package router_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/infuse"
+	"github.com/sclevine/infuse/router"
+)
+
+func TestRouter(t *testing.T) {
+	r := router.New()
+	r.HandleFunc("GET", "/", buildOutputHandler("root"))
+	r.HandleFunc("GET", "/users/{id}", buildOutputHandler("user"))
+	r.HandleFunc("GET", "/users/me", buildOutputHandler("me"))
+	r.HandleFunc("POST", "/users/{id}/posts/{post}", buildOutputHandler("post"))
+	r.HandleFunc("GET", "/static/{path...}", buildOutputHandler("static"))
+
+	testRoute(t, r, "GET", "/", 200, "root: map[]")
+	testRoute(t, r, "GET", "/users/42", 200, "user: map[id:42]")
+	testRoute(t, r, "GET", "/users/me", 200, "me: map[]")
+	testRoute(t, r, "POST", "/users/42/posts/7", 200, "post: map[id:42 post:7]")
+	testRoute(t, r, "GET", "/static/css/main.css", 200, "static: map[path:css/main.css]")
+	testRoute(t, r, "HEAD", "/users/42", 200, "user: map[id:42]")
+	testRoute(t, r, "GET", "/users/a%2Fb", 200, "user: map[id:a/b]")
+	testRoute(t, r, "GET", "/users/m%65", 200, "me: map[]")
+	testRoute(t, r, "GET", "/static/a%20b/c%2Fd.css", 200, "static: map[path:a b/c/d.css]")
+	testRoute(t, r, "GET", "/users/", 404, "404 page not found")
+	testRoute(t, r, "GET", "/unknown", 404, "404 page not found")
+
+	response := testRoute(t, r, "DELETE", "/users/42", 405, "Method Not Allowed")
+	if allow := response.Header().Get("Allow"); allow != "GET, HEAD" {
+		t.Fatalf("Expected Allow header GET, HEAD, got %s.", allow)
+	}
+	response = testRoute(t, r, "GET", "/users/42/posts/7", 405, "Method Not Allowed")
+	if allow := response.Header().Get("Allow"); allow != "POST" {
+		t.Fatalf("Expected Allow header POST, got %s.", allow)
+	}
+}
+
+func TestRouterCustomErrors(t *testing.T) {
+	r := router.New()
+	r.HandleFunc("GET", "/users/{id}", buildOutputHandler("user"))
+	r.HandleFunc("PUT", "/users/{id}", buildOutputHandler("user"))
+	r.NotFound = http.HandlerFunc(func(response http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(response, "custom not found")
+	})
+	r.MethodNotAllowed = http.HandlerFunc(func(response http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(response, "custom not allowed: %s", response.Header().Get("Allow"))
+	})
+
+	testRoute(t, r, "GET", "/posts", 200, "custom not found")
+	testRoute(t, r, "DELETE", "/users/42", 200, "custom not allowed: GET, HEAD, PUT")
+}
+
+var groupFixture = `
+start base
+params: map[id:42]
+start admin
+user: map[id:42]
+value: admin
+end admin
+end base`
+
+func TestGroup(t *testing.T) {
+	base := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		fmt.Fprintln(response, "start base")
+		fmt.Fprintf(response, "params: %v\n", router.Params(response))
+		infuse.Next(response, request)
+		fmt.Fprintln(response, "end base")
+	})
+	admin := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		fmt.Fprintln(response, "start admin")
+		infuse.Set(response, "admin")
+		infuse.Next(response, request)
+		fmt.Fprintln(response, "end admin")
+	})
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		buildOutputHandler("user")(response, request)
+		fmt.Fprintf(response, "value: %s\n", infuse.Get(response))
+	})
+
+	r := router.New()
+	api := r.Group("/api", base)
+	api.Group("/admin", admin).Handle("GET", "/users/{id}", handler)
+	api.HandleFunc("GET", "/users/{id}", buildOutputHandler("user"))
+
+	testRoute(t, r, "GET", "/api/admin/users/42", 200, groupFixture)
+	testRoute(t, r, "GET", "/api/users/42", 200, "start base\nparams: map[id:42]\nuser: map[id:42]\nend base")
+	if layers := infuse.Layers(base); len(layers) != 1 {
+		t.Fatalf("Expected base to be unmodified, got %d layers.", len(layers))
+	}
+}
+
+func TestParamsWithoutRouter(t *testing.T) {
+	if param := router.Param(httptest.NewRecorder(), "id"); param != "" {
+		t.Fatalf("Expected no parameter, got %s.", param)
+	}
+	if params := router.Params(httptest.NewRecorder()); params != nil {
+		t.Fatalf("Expected no parameters, got %v.", params)
+	}
+}
+
+func TestInvalidPatterns(t *testing.T) {
+	r := router.New()
+	r.HandleFunc("GET", "/users/{id}", buildOutputHandler("user"))
+
+	for _, pattern := range []string{
+		"users",
+		"/users/{name}",
+		"/users/{}",
+		"/static/{path...}/more",
+		"/users/{id}",
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("Expected pattern %s to panic.", pattern)
+				}
+			}()
+			r.HandleFunc("GET", pattern, buildOutputHandler("invalid"))
+		}()
+	}
+}
+
+func testRoute(t *testing.T, handler http.Handler, method, path string, status int, fixture string) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(method, path, nil))
+	if response.Code != status {
+		t.Fatalf("Expected status %d for %s %s, got %d.", status, method, path, response.Code)
+	}
+	expected := strings.TrimSpace(fixture)
+	if body := strings.TrimSpace(response.Body.String()); body != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s\n", expected, body)
+	}
+	return response
+}
+
+func buildOutputHandler(name string) func(http.ResponseWriter, *http.Request) {
+	return func(response http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(response, "%s: %v\n", name, router.Params(response))
+		if id := router.Param(response, "id"); id != "" && id != router.Params(response)["id"] {
+			panic("Expected Param to match Params.")
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sclevine/infuse"
+)
+
+// A node matches a single path segment. The routes of a node are served
+// for paths that end at the node, and the params of a node are the names
+// of the parameters matched on the way to it.
+type node struct {
+	static   map[string]*node
+	param    *node
+	wildcard *node
+	name     string
+	routes   map[string]infuse.Handler
+	params   []string
+}
+
+func (n *node) insert(method, pattern string, handler infuse.Handler) {
+	if !strings.HasPrefix(pattern, "/") {
+		panic(fmt.Sprintf("router: pattern %q must begin with /", pattern))
+	}
+	current := n
+	var params []string
+	parts := segments(pattern)
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "...}"):
+			if i != len(parts)-1 {
+				panic(fmt.Sprintf("router: wildcard in pattern %q must be the last segment", pattern))
+			}
+			name := part[1 : len(part)-4]
+			current.wildcard = current.wildcard.child(name, pattern)
+			current = current.wildcard
+			params = append(params, name)
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			name := part[1 : len(part)-1]
+			current.param = current.param.child(name, pattern)
+			current = current.param
+			params = append(params, name)
+		default:
+			if current.static == nil {
+				current.static = make(map[string]*node)
+			}
+			if current.static[part] == nil {
+				current.static[part] = &node{}
+			}
+			current = current.static[part]
+		}
+	}
+	if current.routes == nil {
+		current.routes = make(map[string]infuse.Handler)
+	}
+	if _, ok := current.routes[method]; ok {
+		panic(fmt.Sprintf("router: route already registered for %s %s", method, pattern))
+	}
+	current.routes[method] = handler
+	current.params = params
+}
+
+// child returns the node if it already exists, or a new node for a
+// parameter with the provided name.
+func (n *node) child(name, pattern string) *node {
+	if name == "" {
+		panic(fmt.Sprintf("router: parameter in pattern %q must have a name", pattern))
+	}
+	if n == nil {
+		return &node{name: name}
+	}
+	if n.name != name {
+		panic(fmt.Sprintf("router: parameter {%s} in pattern %q conflicts with {%s}", name, pattern, n.name))
+	}
+	return n
+}
+
+// match returns the node with routes that matches the provided escaped path
+// segments, along with the escaped values of any parameters matched on the
+// way. Literal segments are matched after unescaping, so that an escaped
+// slash within a segment does not separate segments.
+func (n *node) match(segments, values []string) (*node, []string) {
+	if len(segments) == 0 {
+		if n.routes == nil {
+			return nil, nil
+		}
+		return n, values
+	}
+	segment := segments[0]
+	if child, ok := n.static[unescape(segment)]; ok {
+		if match, matched := child.match(segments[1:], values); match != nil {
+			return match, matched
+		}
+	}
+	if n.param != nil && segment != "" {
+		if match, matched := n.param.match(segments[1:], append(values, segment)); match != nil {
+			return match, matched
+		}
+	}
+	if n.wildcard != nil && n.wildcard.routes != nil {
+		return n.wildcard, append(values, strings.Join(segments, "/"))
+	}
+	return nil, nil
+}
@@ -0,0 +1,56 @@
+package infuse
+
+import "net/http"
+
+// A scope determines whether the context values of an infuse.Handler that is
+// nested in another infuse.Handler are linked to the context values of the
+// outer infuse.Handler.
+type scope int
+
+const (
+	scopeIsolated scope = iota
+	scopeInherited
+	scopeShared
+)
+
+// InheritContext is an http.HandlerFunc that calls infuse.Next after
+// allowing the http.Handlers attached to the same infuse.Handler to read the
+// context values of the infuse.Handler it is nested in. Keys that do not have
+// an associated context value are looked up in the outer infuse.Handler.
+// Context values set or deleted by the nested infuse.Handler are not visible
+// to the outer infuse.Handler, and deleting a context value does not hide an
+// inherited value.
+//
+// By default, the context values of a nested infuse.Handler are isolated from
+// the infuse.Handler it is nested in. As the context values are shared by
+// every http.Handler attached to the nested infuse.Handler, InheritContext
+// is typically attached first:
+//
+//   auth := infuse.New().HandleFunc(infuse.InheritContext).HandleFunc(authenticate)
+//
+// If the infuse.Handler is not nested in another infuse.Handler,
+// InheritContext only calls infuse.Next.
+func InheritContext(response http.ResponseWriter, request *http.Request) {
+	if sharedResponse, ok := find(response); ok {
+		sharedResponse.setScope(scopeInherited)
+	}
+	Next(response, request)
+}
+
+// ShareContext is the same as InheritContext, but context values set or
+// deleted by the http.Handlers attached to the same infuse.Handler are set in
+// or deleted from the infuse.Handler it is nested in. This allows a reusable
+// infuse.Handler to set context values for the http.Handlers attached after
+// it in the outer infuse.Handler.
+func ShareContext(response http.ResponseWriter, request *http.Request) {
+	if sharedResponse, ok := find(response); ok {
+		sharedResponse.setScope(scopeShared)
+	}
+	Next(response, request)
+}
+
+func (s *state) setScope(scope scope) {
+	if s.parent != nil {
+		s.scope = scope
+	}
+}
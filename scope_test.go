@@ -0,0 +1,61 @@
+package infuse_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+func buildScopeHandler(scope http.HandlerFunc) (infuse.Handler, *string) {
+	var outer string
+	nested := infuse.New().HandleFunc(scope).HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(response, "inner sees %v\n", infuse.Value(response, firstKey{}))
+		infuse.SetValue(response, firstKey{}, "inner value")
+		infuse.SetValue(response, secondKey{}, "inner only")
+		fmt.Fprintf(response, "inner sets %v\n", infuse.Value(response, firstKey{}))
+	})
+
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.SetValue(response, firstKey{}, "outer value")
+		infuse.Next(response, request)
+		outer = fmt.Sprintf("%v %v", infuse.Value(response, firstKey{}), infuse.Value(response, secondKey{}))
+	})
+	return handler.Stack(nested), &outer
+}
+
+func TestIsolatedContext(t *testing.T) {
+	handler, outer := buildScopeHandler(func(response http.ResponseWriter, request *http.Request) {
+		infuse.Next(response, request)
+	})
+	testHandlerResponse(t, serve(handler), "inner sees <nil>\ninner sets inner value")
+	if *outer != "outer value <nil>" {
+		t.Fatalf("Expected outer context to be unchanged, got: %s", *outer)
+	}
+}
+
+func TestInheritContext(t *testing.T) {
+	handler, outer := buildScopeHandler(infuse.InheritContext)
+	testHandlerResponse(t, serve(handler), "inner sees outer value\ninner sets inner value")
+	if *outer != "outer value <nil>" {
+		t.Fatalf("Expected outer context to be unchanged, got: %s", *outer)
+	}
+}
+
+func TestShareContext(t *testing.T) {
+	handler, outer := buildScopeHandler(infuse.ShareContext)
+	testHandlerResponse(t, serve(handler), "inner sees outer value\ninner sets inner value")
+	if *outer != "inner value inner only" {
+		t.Fatalf("Expected outer context to be changed, got: %s", *outer)
+	}
+}
+
+func TestInheritContextWithoutParent(t *testing.T) {
+	handler := infuse.New().HandleFunc(infuse.InheritContext)
+	handler = handler.HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		infuse.Set(response, "value")
+		fmt.Fprint(response, infuse.Get(response))
+	})
+	testHandlerResponse(t, serve(handler), "value")
+}
@@ -0,0 +1,69 @@
+package infuse
+
+import "net/http"
+
+func (l *layer) Prepend(handler http.Handler) Handler {
+	added := (*layer)(nil).attach("", KindHandle, handler, handler).(*layer)
+	if l == nil {
+		return added
+	}
+	return l.splice(func(*layer) bool { return true }, func(base, match *layer) *layer {
+		return match.relink(added.link(base))
+	})
+}
+
+func (l *layer) InsertBefore(name string, handler http.Handler) Handler {
+	added := (*layer)(nil).attach("", KindHandle, handler, handler).(*layer)
+	return l.splice(named(name), func(base, match *layer) *layer {
+		return match.relink(added.link(base))
+	})
+}
+
+func (l *layer) Replace(name string, handler http.Handler) Handler {
+	added := (*layer)(nil).attach(name, KindHandle, handler, handler).(*layer)
+	return l.splice(named(name), func(base, _ *layer) *layer {
+		return added.link(base)
+	})
+}
+
+func (l *layer) Without(name string) Handler {
+	return l.splice(named(name), func(base, _ *layer) *layer {
+		return base
+	})
+}
+
+// named returns a match function for layers attached with the provided name.
+// An empty name matches no layers, since unnamed layers have no name to
+// match.
+func named(name string) func(*layer) bool {
+	return func(layer *layer) bool {
+		return name != "" && layer.name == name
+	}
+}
+
+// splice returns a copy of the chain in which the earliest layer that
+// matches is replaced with the layer returned by edit. The edit function is
+// passed the layers attached before the matching layer, which are shared with
+// the original chain, and the matching layer. The layers attached after the
+// matching layer are copied onto the result of edit. If no layer matches,
+// the chain is returned unchanged.
+func (l *layer) splice(match func(*layer) bool, edit func(base, match *layer) *layer) Handler {
+	layers := l.flatten()
+	for i := len(layers) - 1; i >= 0; i-- {
+		if !match(layers[i]) {
+			continue
+		}
+		current := edit(layers[i].prev, layers[i])
+		for j := i - 1; j >= 0; j-- {
+			current = layers[j].relink(current)
+		}
+		return current
+	}
+	return l
+}
+
+// relink returns a copy of the layer attached after the provided layer.
+func (l *layer) relink(prev *layer) *layer {
+	copied := *l
+	return copied.link(prev)
+}
@@ -0,0 +1,89 @@
+package infuse_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+func namedHandler(name string) (string, http.Handler) {
+	return name, http.HandlerFunc(buildHandler(name, 1))
+}
+
+func TestPrepend(t *testing.T) {
+	original := infuse.New().HandleFunc(buildHandler("second", 1)).HandleFunc(buildHandler("third", 1))
+	handler := original.Prepend(http.HandlerFunc(buildHandler("first", 1)))
+	testHandlerResponse(t, serve(handler), threeHandlerFixture)
+
+	handler = infuse.New().Prepend(http.HandlerFunc(buildHandler("third", 1)))
+	handler = handler.Prepend(http.HandlerFunc(buildHandler("second", 1)))
+	handler = handler.Prepend(http.HandlerFunc(buildHandler("first", 1)))
+	testHandlerResponse(t, serve(handler), threeHandlerFixture)
+
+	if layers := infuse.Layers(handler); !strings.Contains(layers[0].Source, "splice_test.go:") {
+		t.Fatalf("Expected prepended layer to be attached in splice_test.go, got %s.", layers[0].Source)
+	}
+}
+
+func TestInsertBefore(t *testing.T) {
+	original := infuse.New().HandleFunc(buildHandler("first", 1))
+	original = original.HandleNamed(namedHandler("third"))
+	handler := original.InsertBefore("third", http.HandlerFunc(buildHandler("second", 1)))
+	testHandlerResponse(t, serve(handler), threeHandlerFixture)
+
+	if unchanged := original.InsertBefore("missing", http.HandlerFunc(buildHandler("second", 1))); unchanged != original {
+		t.Fatal("Expected handler to be unchanged when no layer matches.")
+	}
+	if layers := infuse.Layers(original); len(layers) != 2 {
+		t.Fatal("Expected original handler to be unchanged.")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	original := infuse.New().HandleFunc(buildHandler("first", 1))
+	original = original.HandleNamed(namedHandler("second"))
+	original = original.HandleFunc(buildHandler("third", 1))
+	handler := original.Replace("second", http.HandlerFunc(buildHandler("replaced", 1)))
+
+	testHandlerResponse(t, serve(original), threeHandlerFixture)
+	testHandlerResponse(t, serve(handler.Replace("second", http.HandlerFunc(buildHandler("second", 1)))), threeHandlerFixture)
+	if layers := infuse.Layers(handler); layers[1].Name != "second" {
+		t.Fatalf("Expected replacement to keep the name, got: %s", layers[1])
+	}
+}
+
+func TestWithout(t *testing.T) {
+	original := infuse.New().HandleFunc(buildHandler("first", 1))
+	original = original.HandleNamed(namedHandler("tracing"))
+	original = original.HandleFunc(buildHandler("second", 1))
+	original = original.HandleFunc(buildHandler("third", 1))
+
+	testHandlerResponse(t, serve(original.Without("tracing")), threeHandlerFixture)
+	if layers := infuse.Layers(original); len(layers) != 4 {
+		t.Fatal("Expected original handler to be unchanged.")
+	}
+
+	handler := infuse.New().HandleNamed(namedHandler("only")).Without("only")
+	if body := serve(handler); body != "" {
+		t.Fatalf("Expected empty handler, got: %q", body)
+	}
+}
+
+func TestSpliceWithEmptyName(t *testing.T) {
+	original := infuse.New().HandleFunc(buildHandler("first", 1))
+	original = original.HandleFunc(buildHandler("second", 1))
+	original = original.HandleFunc(buildHandler("third", 1))
+
+	for _, handler := range []infuse.Handler{
+		original.Without(""),
+		original.InsertBefore("", http.HandlerFunc(buildHandler("inserted", 1))),
+		original.Replace("", http.HandlerFunc(buildHandler("replaced", 1))),
+	} {
+		if layers := infuse.Layers(handler); len(layers) != 3 {
+			t.Fatalf("Expected handler to be unchanged, got: %v", layers)
+		}
+		testHandlerResponse(t, serve(handler), threeHandlerFixture)
+	}
+}
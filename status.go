@@ -0,0 +1,106 @@
+package infuse
+
+import "net/http"
+
+// Status returns the status code written to the response that the
+// infuse.Handler is being served with. Status will return 0 if the response
+// headers have not been written yet, if the connection was hijacked, or if
+// the provided response is invalid.
+//
+// Only writes that reach the response that the infuse.Handler is served with
+// are considered. Writes to a replacement http.ResponseWriter provided to
+// NextWith are only considered once the replacement writes them to the
+// response.
+//
+// As the status code is shared by all http.Handlers attached to the same
+// infuse.Handler, middleware can inspect the status code written by
+// subsequent http.Handlers after calling Next, without wrapping the response:
+//
+//   func logger(response http.ResponseWriter, request *http.Request) {
+//      infuse.Next(response, request)
+//      log.Printf("%s %s: %d", request.Method, request.URL, infuse.Status(response))
+//   }
+func Status(response http.ResponseWriter) int {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return 0
+	}
+	return sharedResponse.writtenStatus()
+}
+
+// BytesWritten returns the number of bytes of the response body written to
+// the response that the infuse.Handler is being served with. Like Status,
+// it only considers writes that reach that response. BytesWritten will return
+// 0 if the provided response is invalid.
+func BytesWritten(response http.ResponseWriter) int64 {
+	sharedResponse, ok := find(response)
+	if !ok {
+		return 0
+	}
+	return sharedResponse.bytesWritten()
+}
+
+// HeaderWritten reports whether the response headers were written to the
+// response that the infuse.Handler is being served with, either explicitly
+// with WriteHeader or implicitly by writing to or flushing the response. It
+// also reports true if the connection was hijacked. Once the response
+// headers are written, they can no longer be changed. HeaderWritten will
+// return false if the provided response is invalid.
+func HeaderWritten(response http.ResponseWriter) bool {
+	sharedResponse, ok := find(response)
+	return ok && sharedResponse.headerWritten()
+}
+
+func (c *contextualResponse) WriteHeader(status int) {
+	c.writingHeader(status)
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *contextualResponse) Write(data []byte) (int, error) {
+	c.writingHeader(http.StatusOK)
+	n, err := c.ResponseWriter.Write(data)
+	c.wrote(int64(n))
+	return n, err
+}
+
+// writingHeader records the status code of the response headers that are
+// about to be written and calls any hooks registered with BeforeWrite, if
+// the contextualResponse is tracked and the headers have not been written
+// yet. Informational (1xx) headers other than
+// 101 Switching Protocols may be followed by other headers, so they are not
+// recorded.
+func (c *contextualResponse) writingHeader(status int) {
+	if !c.tracked || c.wroteHeader {
+		return
+	}
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		return
+	}
+	c.wroteHeader = true
+	c.status = status
+	c.runHooks(status)
+}
+
+func (c *contextualResponse) wrote(n int64) {
+	if c.tracked {
+		c.written += n
+	}
+}
+
+func (c *contextualResponse) hijacking() {
+	if c.tracked {
+		c.wroteHeader = true
+	}
+}
+
+func (s *state) headerWritten() bool {
+	return s.wroteHeader
+}
+
+func (s *state) writtenStatus() int {
+	return s.status
+}
+
+func (s *state) bytesWritten() int64 {
+	return s.written
+}
@@ -0,0 +1,85 @@
+package infuse_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+var statusFixture = `
+some body
+before: 0 0 false
+after: 201 10 true`
+
+func TestStatus(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		status := fmt.Sprintf("before: %d %d %t\n", infuse.Status(response), infuse.BytesWritten(response), infuse.HeaderWritten(response))
+		infuse.Next(response, request)
+		status += fmt.Sprintf("after: %d %d %t\n", infuse.Status(response), infuse.BytesWritten(response), infuse.HeaderWritten(response))
+		fmt.Fprint(response, status)
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		response.WriteHeader(http.StatusCreated)
+		response.WriteHeader(http.StatusAccepted)
+		fmt.Fprintln(response, "some body")
+	})
+	testHandlerResponse(t, serve(handler), statusFixture)
+}
+
+func TestStatusAfterInformationalHeaders(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		response.WriteHeader(http.StatusEarlyHints)
+		if infuse.HeaderWritten(response) {
+			t.Fatal("Expected informational headers not to be recorded.")
+		}
+		response.(http.Flusher).Flush()
+		if status := infuse.Status(response); status != http.StatusOK {
+			t.Fatalf("Expected status 200 after flush, got %d.", status)
+		}
+	})
+	serve(handler)
+}
+
+var replacementStatusFixture = `
+buffered
+buffered: 0 0
+committed: 200 9`
+
+func TestStatusWithReplacement(t *testing.T) {
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		buffer := &bufferedResponse{ResponseWriter: response}
+		infuse.NextWith(response, buffer, request)
+		status := fmt.Sprintf("buffered: %d %d\n", infuse.Status(response), infuse.BytesWritten(response))
+		buffer.body.WriteTo(response)
+		status += fmt.Sprintf("committed: %d %d\n", infuse.Status(response), infuse.BytesWritten(response))
+		fmt.Fprint(response, status)
+	})
+	handler = handler.HandleFunc(func(response http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(response, "buffered")
+	})
+	testHandlerResponse(t, serve(handler), replacementStatusFixture)
+}
+
+func TestInvalidResponseForStatus(t *testing.T) {
+	if status := infuse.Status(nil); status != 0 {
+		t.Fatalf("Expected no status from invalid response, got %d.", status)
+	}
+	if written := infuse.BytesWritten(nil); written != 0 {
+		t.Fatalf("Expected no bytes written from invalid response, got %d.", written)
+	}
+	if infuse.HeaderWritten(nil) {
+		t.Fatal("Expected headers not to be written for invalid response.")
+	}
+}
+
+type bufferedResponse struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
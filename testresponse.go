@@ -0,0 +1,27 @@
+package infuse
+
+import "net/http"
+
+// NewTestResponse returns an http.ResponseWriter that writes to the provided
+// response, for testing an http.Handler that is normally attached to an
+// infuse.Handler without serving an infuse.Handler. The returned response
+// has its own context values and is positioned as if it were provided to
+// the first http.Handler attached to an infuse.Handler, followed only by the
+// provided downstream http.Handler. Each call to Next with the returned
+// response serves downstream. If downstream is nil, Next returns false.
+//
+// If onSet is not nil, it is called with the key and the new value each time
+// a context value is set with the returned response (with Set, SetValue, or
+// Key.Set), so that tests can record which context values were set.
+//
+// Unlike a response provided by an infuse.Handler, the returned response
+// remains valid until it is discarded. The mock package provides a
+// convenient wrapper around NewTestResponse (see mock.NewResponse).
+func NewTestResponse(response http.ResponseWriter, downstream http.Handler, onSet func(key, value interface{})) http.ResponseWriter {
+	sharedResponse := newLayeredResponse(response)
+	sharedResponse.onSet = onSet
+	if downstream != nil {
+		sharedResponse.layers = []*layer{(*layer)(nil).attach("", KindHandle, downstream, downstream).(*layer)}
+	}
+	return sharedResponse.extend()
+}
@@ -0,0 +1,50 @@
+package infuse_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+func TestNewTestResponse(t *testing.T) {
+	var sets []string
+	recorder := httptest.NewRecorder()
+	downstream := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(response, "downstream sees %v\n", infuse.Get(response))
+		infuse.Fail(response, errors.New("downstream error"))
+	})
+	response := infuse.NewTestResponse(recorder, downstream, func(key, value interface{}) {
+		sets = append(sets, fmt.Sprint(value))
+	})
+
+	middleware := func(response http.ResponseWriter, request *http.Request) {
+		infuse.Set(response, "value")
+		if err := infuse.NextErr(response, request); err == nil || err.Error() != "downstream error" {
+			t.Fatalf("Expected downstream error, got %v.", err)
+		}
+	}
+	middleware(response, &http.Request{})
+	middleware(response, &http.Request{})
+
+	testHandlerResponse(t, recorder.Body.String(), "downstream sees value\ndownstream sees value")
+	if fmt.Sprint(sets) != "[value value]" {
+		t.Fatalf("Unexpected sets: %v", sets)
+	}
+	if infuse.Status(response) != http.StatusOK {
+		t.Fatalf("Expected status to be tracked, got %d.", infuse.Status(response))
+	}
+}
+
+func TestNewTestResponseWithoutDownstream(t *testing.T) {
+	response := infuse.NewTestResponse(httptest.NewRecorder(), nil, nil)
+	if infuse.Next(response, &http.Request{}) {
+		t.Fatal("Expected next to fail without downstream.")
+	}
+	if !infuse.Set(response, "value") || infuse.Get(response) != "value" {
+		t.Fatal("Expected context values to work without downstream.")
+	}
+}
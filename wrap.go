@@ -0,0 +1,45 @@
+package infuse
+
+import (
+	"context"
+	"net/http"
+)
+
+type wrapKey struct{}
+
+// Middleware returns a standard middleware function that serves the provided
+// infuse.Handler in front of the next http.Handler. The next http.Handler is
+// served when the last http.Handler attached to the infuse.Handler calls
+// infuse.Next (see Handler.Then). This allows an infuse.Handler to be used
+// with routers and libraries that expect middleware of the form:
+//
+//   func(next http.Handler) http.Handler
+func Middleware(handler Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return handler.Then(next)
+	}
+}
+
+func (l *layer) Wrap(middleware func(http.Handler) http.Handler) Handler {
+	wrapped := middleware(http.HandlerFunc(wrapNext))
+	handler := http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		ctx := context.WithValue(request.Context(), wrapKey{}, response)
+		wrapped.ServeHTTP(response, request.WithContext(ctx))
+	})
+	return l.attach("", KindWrap, wrapped, handler)
+}
+
+// wrapNext is the next http.Handler provided to middleware attached with
+// Wrap. It continues the middleware chain from the provided response if it
+// is or unwraps to the response provided to the layer. Otherwise, it
+// continues the chain from the response provided to the layer, which is
+// carried by the request context.
+func wrapNext(response http.ResponseWriter, request *http.Request) {
+	if _, ok := find(response); ok {
+		Next(response, request)
+		return
+	}
+	if original, ok := request.Context().Value(wrapKey{}).(http.ResponseWriter); ok {
+		NextWith(original, response, request)
+	}
+}
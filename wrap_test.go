@@ -0,0 +1,116 @@
+package infuse_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/infuse"
+)
+
+type wrapRequestKey struct{}
+
+var wrapFixture = `
+start first
+attempting next for first
+START MIDDLEWARE
+START LAST
+ATTEMPTING NEXT FOR LAST
+NO NEXT FOR LAST
+END LAST
+END MIDDLEWARE
+finished next for first
+end first`
+
+func TestWrap(t *testing.T) {
+	middleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			upper := &nonUnwrappingResponse{response}
+			fmt.Fprintln(upper, "start middleware")
+			ctx := context.WithValue(request.Context(), wrapRequestKey{}, "request value")
+			next.ServeHTTP(upper, request.WithContext(ctx))
+			fmt.Fprintln(upper, "end middleware")
+		})
+	}
+
+	handler := infuse.New().HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		infuse.Set(response, "shared value")
+		buildHandler("first", 1)(response, request)
+	})
+	handler = handler.Wrap(middleware)
+	handler = handler.HandleFunc(func(response http.ResponseWriter, request *http.Request) {
+		if infuse.Get(response) != "shared value" {
+			t.Fatal("Expected wrapped middleware to preserve context values.")
+		}
+		if request.Context().Value(wrapRequestKey{}) != "request value" {
+			t.Fatal("Expected wrapped middleware to replace the request.")
+		}
+		buildHandler("last", 1)(response, request)
+	})
+
+	testHandlerResponse(t, serve(handler), wrapFixture)
+
+	if layers := infuse.Layers(handler); layers[1].Kind != infuse.KindWrap {
+		t.Fatalf("Expected wrapped middleware to be described, got: %s", layers[1])
+	}
+}
+
+func TestWrapWithNewContext(t *testing.T) {
+	middleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			next.ServeHTTP(&upperResponse{ResponseWriter: response}, request.WithContext(context.Background()))
+		})
+	}
+
+	handler := infuse.New().Wrap(middleware)
+	handler = handler.HandleFunc(buildHandler("last", 0))
+	testHandlerResponse(t, serve(handler), "START LAST\nEND LAST")
+}
+
+func TestWrapCalledOnce(t *testing.T) {
+	constructed := 0
+	middleware := func(next http.Handler) http.Handler {
+		constructed++
+		count := 0
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			count++
+			fmt.Fprintf(response, "count=%d\n", count)
+			next.ServeHTTP(response, request)
+		})
+	}
+
+	handler := infuse.New().Wrap(middleware).HandleFunc(buildHandler("last", 0))
+	for i := 1; i <= 3; i++ {
+		testHandlerResponse(t, serve(handler), fmt.Sprintf("count=%d\nstart last\nend last", i))
+	}
+	if constructed != 1 {
+		t.Fatalf("Expected middleware to be called once, but it was called %d times.", constructed)
+	}
+}
+
+func TestWrapWithoutNext(t *testing.T) {
+	middleware := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, _ *http.Request) {
+			fmt.Fprintln(response, "denied")
+		})
+	}
+
+	handler := infuse.New().Wrap(middleware).HandleFunc(buildHandler("never", 0))
+	testHandlerResponse(t, serve(handler), "denied")
+}
+
+func TestMiddleware(t *testing.T) {
+	handler := infuse.New().HandleFunc(buildHandler("first", 1)).HandleFunc(buildHandler("second", 1))
+	middleware := infuse.Middleware(handler)
+	testHandlerResponse(t, serve(middleware(http.HandlerFunc(buildHandler("third", 1)))), threeHandlerFixture)
+}
+
+type nonUnwrappingResponse struct {
+	http.ResponseWriter
+}
+
+func (n *nonUnwrappingResponse) Write(data []byte) (int, error) {
+	return n.ResponseWriter.Write([]byte(strings.ToUpper(string(data))))
+}